@@ -0,0 +1,317 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"github.com/go-framework/configurer"
+	"github.com/go-framework/configurer/schema"
+	"github.com/go-framework/configurer/templates/environment"
+	"github.com/go-framework/event/inapp"
+	"github.com/go-framework/logger"
+)
+
+// App is an application, it owns the logger, the event bus and the configuration,
+// and runs the registered components through the Init/Start/Stop phases.
+type App struct {
+	options   *Options
+	container *Container
+
+	mu         sync.Mutex         // mu protects the fields below.
+	components []Component        // registered components in registration order.
+	running    bool               // running is true between Run and its return.
+	cancel     context.CancelFunc // cancel cancels the run context.
+	done       chan struct{}      // done is closed when Run returns.
+	stopCtx    context.Context    // stopCtx is the context passed by Shutdown.
+	err        error              // err is the Run result.
+}
+
+// New App with options.
+func New(opts ...Option) *App {
+	options := GetDefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	a := &App{
+		options:   options,
+		container: NewContainer(),
+	}
+
+	// supply the application owned instances.
+	a.container.Supply(a)
+	if options.Logger != nil {
+		a.container.Supply(options.Logger)
+	}
+	if options.Event != nil {
+		a.container.Supply(options.Event)
+	}
+	if options.Config != nil {
+		a.container.SupplyAs((*configurer.Configurer)(nil), options.Config)
+	}
+
+	return a
+}
+
+// Name returns the application name.
+func (a *App) Name() string {
+	return a.options.Name
+}
+
+// Logger returns the application logger, it is a no-op logger when the
+// logger option is nil.
+func (a *App) Logger() *logger.Logger {
+	if a.options.Logger == nil {
+		return zap.NewNop()
+	}
+	return a.options.Logger
+}
+
+// Event returns the application event bus.
+func (a *App) Event() *inapp.Event {
+	return a.options.Event
+}
+
+// Environment returns the application run environment.
+func (a *App) Environment() environment.Environment {
+	return a.options.Environment
+}
+
+// Config returns the application configuration.
+func (a *App) Config() configurer.Configurer {
+	return a.options.Config
+}
+
+// Schema returns the application configuration schema, default is NewConfigSchema.
+func (a *App) Schema() *schema.Schema {
+	if a.options.Schema == nil {
+		return NewConfigSchema()
+	}
+	return a.options.Schema
+}
+
+// Container returns the application dependency injection container, the App,
+// its logger, event bus and configuration are supplied.
+func (a *App) Container() *Container {
+	return a.container
+}
+
+// Register components, the component name must be unique.
+func (a *App) Register(components ...Component) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, component := range components {
+		if component == nil {
+			return ErrNilComponent
+		}
+		for _, item := range a.components {
+			if item.Name() == component.Name() {
+				return fmt.Errorf("%w: %s", ErrDuplicateComponent, component.Name())
+			}
+		}
+		a.components = append(a.components, component)
+	}
+
+	return nil
+}
+
+// Components returns the registered components in registration order.
+func (a *App) Components() []Component {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]Component(nil), a.components...)
+}
+
+// Run initializes and starts all components, then blocks until ctx is done,
+// Shutdown is called or one of the options signals is received. The started
+// components are stopped in reverse order within their stop deadlines and the
+// overall shutdown budget, then the in-flight event publishes are drained and
+// the logger is flushed. All phase errors are combined with multierr.
+func (a *App) Run(ctx context.Context) error {
+	return a.exec(ctx, nil)
+}
+
+// Exec initializes and starts all components like Run, calls f instead of
+// blocking, then shuts down. It is used by one-shot commands like migrate and
+// healthcheck, f gets the run context which is canceled by Shutdown or signals.
+func (a *App) Exec(ctx context.Context, f func(ctx context.Context) error) error {
+	if f == nil {
+		return a.exec(ctx, func(ctx context.Context) error { return nil })
+	}
+	return a.exec(ctx, f)
+}
+
+func (a *App) exec(ctx context.Context, f func(ctx context.Context) error) error {
+	a.mu.Lock()
+	if a.running {
+		a.mu.Unlock()
+		return ErrAlreadyRunning
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stopNotify := a.notify(cancel)
+	a.running = true
+	a.cancel = cancel
+	a.done = make(chan struct{})
+	a.stopCtx = nil
+	a.err = nil
+	components := append([]Component(nil), a.components...)
+	a.mu.Unlock()
+
+	err := a.run(ctx, components, f)
+	stopNotify()
+	cancel()
+
+	a.mu.Lock()
+	a.running = false
+	a.err = err
+	close(a.done)
+	a.mu.Unlock()
+
+	return err
+}
+
+// Shutdown stops a running application and waits until Run returns or ctx is done.
+func (a *App) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.running {
+		a.mu.Unlock()
+		return ErrNotRunning
+	}
+	a.stopCtx = ctx
+	cancel, done := a.cancel, a.done
+	a.mu.Unlock()
+
+	cancel()
+
+	select {
+	case <-done:
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		return a.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run the components lifecycle, f is called after all components started,
+// the run blocks until ctx done when f is nil. The started components are
+// stopped and the events and logs are drained on every exit, including a
+// failed startup.
+func (a *App) run(ctx context.Context, components []Component, f func(ctx context.Context) error) error {
+	started, err := a.startup(ctx, components)
+	if err == nil {
+		a.Logger().Info("app started", zap.String("app", a.Name()))
+		stopWatch := a.watch(ctx)
+		if f != nil {
+			err = f(ctx)
+		} else {
+			<-ctx.Done()
+		}
+		stopWatch()
+	}
+
+	stopCtx, cancel := a.shutdownContext()
+	defer cancel()
+
+	err = multierr.Append(err, a.stop(stopCtx, started))
+
+	a.Logger().Info("app stopped", zap.String("app", a.Name()), zap.Error(err))
+
+	return multierr.Append(err, a.drain(stopCtx))
+}
+
+// startup checks the environment and the policies, then initializes and
+// starts the components in dependency order. It returns the started
+// components, they are stopped by the caller even on error.
+func (a *App) startup(ctx context.Context, components []Component) ([]Component, error) {
+	// the invalid APP_ENV is not run as the default environment.
+	if _, err := environment.CurrentE(); err != nil {
+		return nil, err
+	}
+	if err := a.checkPolicies(); err != nil {
+		return nil, err
+	}
+
+	g, err := newGraph(components)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := g.levels()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.init(ctx, flatten(levels)); err != nil {
+		return nil, err
+	}
+
+	return a.start(ctx, levels)
+}
+
+// Health checks the components which implement HealthChecker and collects their errors.
+func (a *App) Health(ctx context.Context) (errs error) {
+	for _, component := range a.Components() {
+		if v, ok := component.(HealthChecker); ok {
+			if err := v.Health(ctx); err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("health %s: %w", component.Name(), err))
+			}
+		}
+	}
+	return
+}
+
+// init all components in dependency order and collect their errors.
+func (a *App) init(ctx context.Context, components []Component) (errs error) {
+	for _, component := range components {
+		if err := component.Init(ctx, a); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("init %s: %w", component.Name(), err))
+		}
+	}
+	return
+}
+
+// start components level by level, the components of a level are started in
+// parallel. It stops at the first failed level and returns the started list
+// in start order.
+func (a *App) start(ctx context.Context, levels [][]Component) ([]Component, error) {
+	var started []Component
+	for _, level := range levels {
+		if err := ctx.Err(); err != nil {
+			return started, err
+		}
+
+		var (
+			wg   sync.WaitGroup
+			errs = make([]error, len(level))
+		)
+		for i := range level {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if err := level[i].Start(ctx); err != nil {
+					errs[i] = fmt.Errorf("start %s: %w", level[i].Name(), err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		var err error
+		for i, component := range level {
+			if errs[i] != nil {
+				err = multierr.Append(err, errs[i])
+				continue
+			}
+			started = append(started, component)
+			a.Logger().Debug("component started", zap.String("component", component.Name()))
+		}
+		if err != nil {
+			return started, err
+		}
+	}
+	return started, nil
+}
@@ -0,0 +1,324 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/go-framework/configurer"
+	"github.com/go-framework/configurer/templates/environment"
+)
+
+var (
+	ErrTest = errors.New("test error")
+)
+
+// recorder records lifecycle calls of test components.
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) record(call string) {
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	r.mu.Unlock()
+}
+
+func (r *recorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+type testComponent struct {
+	name     string
+	deps     []string
+	recorder *recorder
+	initErr  error
+	startErr error
+	stopErr  error
+}
+
+func (c *testComponent) Name() string {
+	return c.name
+}
+
+func (c *testComponent) DependsOn() []string {
+	return c.deps
+}
+
+func (c *testComponent) Init(ctx context.Context, app *App) error {
+	c.recorder.record("init " + c.name)
+	return c.initErr
+}
+
+func (c *testComponent) Start(ctx context.Context) error {
+	c.recorder.record("start " + c.name)
+	return c.startErr
+}
+
+func (c *testComponent) Stop(ctx context.Context) error {
+	c.recorder.record("stop " + c.name)
+	return c.stopErr
+}
+
+func TestApp_Run(t *testing.T) {
+	var r = &recorder{}
+	var a = New(WithNameOption("test"))
+
+	if err := a.Register(
+		&testComponent{name: "a", recorder: r},
+		&testComponent{name: "b", deps: []string{"a"}, recorder: r},
+	); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := a.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := []string{"init a", "init b", "start a", "start b", "stop b", "stop a"}
+	if got := r.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestApp_Register(t *testing.T) {
+	var a = New()
+
+	if err := a.Register(&testComponent{name: "a"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := a.Register(&testComponent{name: "a"}); !errors.Is(err, ErrDuplicateComponent) {
+		t.Errorf("Register() error = %v, want %v", err, ErrDuplicateComponent)
+	}
+	if err := a.Register(nil); err != ErrNilComponent {
+		t.Errorf("Register() error = %v, want %v", err, ErrNilComponent)
+	}
+}
+
+func TestApp_Shutdown(t *testing.T) {
+	var r = &recorder{}
+	var a = New()
+
+	a.Register(&testComponent{name: "a", recorder: r, stopErr: ErrTest})
+
+	if err := a.Shutdown(context.Background()); err != ErrNotRunning {
+		t.Errorf("Shutdown() error = %v, want %v", err, ErrNotRunning)
+	}
+
+	var errCh = make(chan error, 1)
+	go func() {
+		errCh <- a.Run(context.Background())
+	}()
+
+	// wait for started.
+	for i := 0; i < 100 && len(r.list()) < 2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := a.Shutdown(context.Background()); !errors.Is(err, ErrTest) {
+		t.Errorf("Shutdown() error = %v, want %v", err, ErrTest)
+	}
+	if err := <-errCh; !errors.Is(err, ErrTest) {
+		t.Errorf("Run() error = %v, want %v", err, ErrTest)
+	}
+}
+
+func TestApp_InitErrors(t *testing.T) {
+	var r = &recorder{}
+	var a = New()
+
+	a.Register(
+		&testComponent{name: "a", recorder: r, initErr: ErrTest},
+		&testComponent{name: "b", recorder: r, initErr: ErrTest},
+	)
+
+	err := a.Run(context.Background())
+	if n := len(multierr.Errors(err)); n != 2 {
+		t.Fatalf("Run() errors = %d, want 2", n)
+	}
+
+	want := []string{"init a", "init b"}
+	if got := r.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+
+	// the failed startup goes through the same shutdown path.
+	core, logs := observer.New(zap.InfoLevel)
+	a = New(WithLoggerOption(zap.New(core)))
+	a.Register(&testComponent{name: "a", recorder: r, initErr: ErrTest})
+	if err := a.Run(context.Background()); !errors.Is(err, ErrTest) {
+		t.Fatalf("Run() error = %v, want %v", err, ErrTest)
+	}
+	if stopped := logs.FilterMessage("app stopped").All(); len(stopped) != 1 {
+		t.Errorf("logs = %v, want app stopped", logs.All())
+	}
+
+	a = New(WithLoggerOption(nil))
+	a.Register(&testComponent{name: "a", recorder: r})
+	if err := a.Exec(context.Background(), nil); err != nil {
+		t.Errorf("Exec() error = %v", err)
+	}
+}
+
+func TestApp_Policies(t *testing.T) {
+	config, err := configurer.New(configurer.MapProvider{
+		"logger": map[string]interface{}{"development": true},
+		"sql":    map[string]interface{}{"driverName": "SQLite"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var r = &recorder{}
+	var a = New(WithEnvironmentOption(environment.Production), WithConfigOption(config))
+	a.Register(&testComponent{name: "a", recorder: r})
+
+	err = a.Run(context.Background())
+	var policyErr *environment.PolicyError
+	if !errors.As(err, &policyErr) || len(policyErr.Violations) != 2 {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := r.list(); len(got) != 0 {
+		t.Errorf("calls = %v, want none", got)
+	}
+
+	a = New(WithEnvironmentOption(environment.SystemTest), WithConfigOption(config))
+	if err := a.Exec(context.Background(), func(ctx context.Context) error { return nil }); err != nil {
+		t.Errorf("Exec() error = %v", err)
+	}
+}
+
+func TestApp_InvalidEnvVar(t *testing.T) {
+	// the environment is detected once per process, so the app runs in a
+	// sub process with the invalid APP_ENV.
+	if os.Getenv("APP_ENV") == "prdo" {
+		err := New().Exec(context.Background(), func(ctx context.Context) error { return nil })
+		if err == nil || !strings.Contains(err.Error(), "APP_ENV") {
+			fmt.Printf("Exec() error = %v, want error of APP_ENV", err)
+			os.Exit(1)
+		}
+		// the environment set by the option replaces the invalid APP_ENV.
+		err = New(WithEnvironmentOption(environment.UnitTest)).Exec(context.Background(), nil)
+		if err != nil || environment.Current() != environment.UnitTest {
+			fmt.Printf("Exec() error = %v, Current() = %v, want %v", err, environment.Current(), environment.UnitTest)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestApp_InvalidEnvVar$")
+	cmd.Env = append(os.Environ(), "APP_ENV=prdo")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("%v: %s", err, out)
+	}
+}
+
+func TestApp_StartError(t *testing.T) {
+	var r = &recorder{}
+	var a = New()
+
+	a.Register(
+		&testComponent{name: "a", recorder: r},
+		&testComponent{name: "b", deps: []string{"a"}, recorder: r, startErr: ErrTest},
+		&testComponent{name: "c", deps: []string{"b"}, recorder: r},
+	)
+
+	if err := a.Run(context.Background()); !errors.Is(err, ErrTest) {
+		t.Fatalf("Run() error = %v, want %v", err, ErrTest)
+	}
+
+	want := []string{"init a", "init b", "init c", "start a", "start b", "stop a"}
+	if got := r.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+// blockComponent blocks in Stop until its context is done or released.
+type blockComponent struct {
+	testComponent
+	release chan struct{}
+}
+
+func (c *blockComponent) Stop(ctx context.Context) error {
+	c.recorder.record("stop " + c.name)
+	<-c.release
+	return nil
+}
+
+func TestApp_StopTimeout(t *testing.T) {
+	var r = &recorder{}
+	var release = make(chan struct{})
+	defer close(release)
+
+	var a = New(
+		WithStopTimeoutOption(time.Second),
+		WithComponentStopTimeoutOption("block", 10*time.Millisecond),
+	)
+
+	a.Register(
+		&testComponent{name: "a", recorder: r},
+		&blockComponent{testComponent: testComponent{name: "block", deps: []string{"a"}, recorder: r}, release: release},
+		&testComponent{name: "c", deps: []string{"block"}, recorder: r},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var begin = time.Now()
+	if err := a.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(begin); elapsed > 500*time.Millisecond {
+		t.Errorf("Run() elapsed = %v, stop deadline is not applied", elapsed)
+	}
+
+	want := []string{"init a", "init block", "init c", "start a", "start block", "start c", "stop c", "stop block", "stop a"}
+	if got := r.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestApp_ShutdownTimeout(t *testing.T) {
+	var r = &recorder{}
+	var release = make(chan struct{})
+	defer close(release)
+
+	var a = New(
+		WithShutdownTimeoutOption(10*time.Millisecond),
+		WithStopTimeoutOption(time.Second),
+	)
+
+	a.Register(
+		&testComponent{name: "a", recorder: r},
+		&blockComponent{testComponent: testComponent{name: "block", recorder: r}, release: release},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var begin = time.Now()
+	err := a.Run(ctx)
+	if elapsed := time.Since(begin); elapsed > 500*time.Millisecond {
+		t.Errorf("Run() elapsed = %v, shutdown budget is not applied", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
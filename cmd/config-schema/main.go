@@ -0,0 +1,46 @@
+// Command config-schema generates the JSON Schema of the framework
+// configuration with the doc comments as descriptions.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/go-framework/app"
+	"github.com/go-framework/configurer/schema"
+)
+
+func main() {
+	var output = flag.String("o", "", "output file, default is stdout")
+	flag.Parse()
+
+	if err := run(*output); err != nil {
+		fmt.Fprintln(os.Stderr, "config-schema:", err)
+		os.Exit(1)
+	}
+}
+
+func run(output string) error {
+	comments, err := app.LoadConfigSchemaComments()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(app.NewConfigSchema(
+		schema.WithTitleOption("go-framework application configuration"),
+		schema.WithCommentsOption(comments),
+	), "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(output, data, 0644)
+}
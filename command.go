@@ -0,0 +1,222 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Command is a node of the command tree, the root command is the binary.
+// Persistent flags are shared by the command and all of its descendants.
+type Command struct {
+	Name    string   // Command name, the first word of the usage line.
+	Aliases []string // Aliases of the command name.
+	Short   string   // Short description shown in the parent help.
+	Long    string   // Long description shown in the command help.
+	Usage   string   // Arguments usage, like "[flags] <key>".
+	Hidden  bool     // Hidden command is not shown in help and completion.
+
+	// Run the command with the positional arguments, a command without Run
+	// prints its help.
+	Run func(ctx context.Context, cmd *Command, args []string) error
+
+	parent          *Command
+	commands        []*Command
+	flags           *pflag.FlagSet
+	persistentFlags *pflag.FlagSet
+	out             io.Writer
+}
+
+// AddCommand adds sub commands.
+func (c *Command) AddCommand(commands ...*Command) {
+	for _, command := range commands {
+		command.parent = c
+		c.commands = append(c.commands, command)
+	}
+}
+
+// Commands returns the sub commands.
+func (c *Command) Commands() []*Command {
+	return c.commands
+}
+
+// Parent returns the parent command, nil for the root command.
+func (c *Command) Parent() *Command {
+	return c.parent
+}
+
+// Root returns the root command.
+func (c *Command) Root() *Command {
+	var root = c
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root
+}
+
+// Path returns the full command path, like "app config print".
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// Flags returns the local flags of the command.
+func (c *Command) Flags() *pflag.FlagSet {
+	if c.flags == nil {
+		c.flags = pflag.NewFlagSet(c.Name, pflag.ContinueOnError)
+	}
+	return c.flags
+}
+
+// PersistentFlags returns the flags shared by the command and its descendants.
+func (c *Command) PersistentFlags() *pflag.FlagSet {
+	if c.persistentFlags == nil {
+		c.persistentFlags = pflag.NewFlagSet(c.Name, pflag.ContinueOnError)
+	}
+	return c.persistentFlags
+}
+
+// InheritedFlags returns the persistent flags of the ancestors.
+func (c *Command) InheritedFlags() *pflag.FlagSet {
+	var fs = pflag.NewFlagSet(c.Name, pflag.ContinueOnError)
+	for p := c.parent; p != nil; p = p.parent {
+		fs.AddFlagSet(p.persistentFlags)
+	}
+	return fs
+}
+
+// LocalFlags returns the local and the persistent flags of the command.
+func (c *Command) LocalFlags() *pflag.FlagSet {
+	var fs = pflag.NewFlagSet(c.Name, pflag.ContinueOnError)
+	fs.AddFlagSet(c.flags)
+	fs.AddFlagSet(c.persistentFlags)
+	return fs
+}
+
+// SetOutput set the output writer of the command and its descendants, default is os.Stdout.
+func (c *Command) SetOutput(w io.Writer) {
+	c.out = w
+}
+
+// Out returns the output writer.
+func (c *Command) Out() io.Writer {
+	for p := c; p != nil; p = p.parent {
+		if p.out != nil {
+			return p.out
+		}
+	}
+	return os.Stdout
+}
+
+// Execute finds the sub command by args, parses its flags and runs it.
+func (c *Command) Execute(ctx context.Context, args []string) error {
+	cmd, args := c.find(args)
+
+	var fs = cmd.LocalFlags()
+	fs.AddFlagSet(cmd.InheritedFlags())
+	fs.SetOutput(ioutil.Discard)
+	var help = addHelpFlag(fs, cmd.Name)
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Path(), err)
+	}
+
+	if help != nil && *help {
+		return cmd.PrintHelp()
+	}
+
+	if cmd.Run == nil {
+		if fs.NArg() > 0 {
+			return fmt.Errorf("%w: %q for %q", ErrUnknownCommand, fs.Arg(0), cmd.Path())
+		}
+		return cmd.PrintHelp()
+	}
+
+	return cmd.Run(ctx, cmd, fs.Args())
+}
+
+// addHelpFlag adds the --help flag and its -h shorthand when the command has
+// not defined them, it returns nil when --help is defined by the command.
+func addHelpFlag(fs *pflag.FlagSet, name string) *bool {
+	if fs.Lookup("help") != nil {
+		return nil
+	}
+	var shorthand = "h"
+	if fs.ShorthandLookup(shorthand) != nil {
+		shorthand = ""
+	}
+	return fs.BoolP("help", shorthand, false, "help for "+name)
+}
+
+// find the sub command by the args, flags may appear before the sub command
+// names. It returns the found command and the args without command names.
+func (c *Command) find(args []string) (*Command, []string) {
+	var (
+		cmd        = c
+		rest       = make([]string, 0, len(args))
+		positional bool // positional is true after the first non command arg.
+	)
+
+	for i := 0; i < len(args); i++ {
+		var arg = args[i]
+		switch {
+		case arg == "--":
+			return cmd, append(rest, args[i:]...)
+		case strings.HasPrefix(arg, "-"):
+			rest = append(rest, arg)
+			// the flag value is the next arg.
+			if !strings.Contains(arg, "=") && cmd.needsValue(arg) && i+1 < len(args) {
+				i++
+				rest = append(rest, args[i])
+			}
+		default:
+			if !positional {
+				if sub := cmd.command(arg); sub != nil {
+					cmd = sub
+					continue
+				}
+			}
+			positional = true
+			rest = append(rest, arg)
+		}
+	}
+
+	return cmd, rest
+}
+
+// command returns the sub command by name or alias.
+func (c *Command) command(name string) *Command {
+	for _, command := range c.commands {
+		if command.Name == name {
+			return command
+		}
+		for _, alias := range command.Aliases {
+			if alias == name {
+				return command
+			}
+		}
+	}
+	return nil
+}
+
+// needsValue reports whether the flag arg needs a separated value.
+func (c *Command) needsValue(arg string) bool {
+	var fs = c.LocalFlags()
+	fs.AddFlagSet(c.InheritedFlags())
+
+	var flag *pflag.Flag
+	if strings.HasPrefix(arg, "--") {
+		flag = fs.Lookup(arg[2:])
+	} else if len(arg) == 2 {
+		flag = fs.ShorthandLookup(arg[1:])
+	}
+
+	return flag != nil && flag.NoOptDefVal == ""
+}
@@ -0,0 +1,338 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-framework/configurer"
+	"github.com/go-framework/configurer/providers/file"
+	"github.com/go-framework/configurer/secret"
+	"github.com/go-framework/configurer/templates/environment"
+)
+
+func newTestRoot(got *[]string) *Command {
+	var root = &Command{Name: "app"}
+	root.PersistentFlags().String("config", "", "config file")
+
+	var print = &Command{
+		Name:  "print",
+		Short: "Print the effective configuration",
+		Run: func(ctx context.Context, cmd *Command, args []string) error {
+			*got = append([]string{cmd.Path()}, args...)
+			return nil
+		},
+	}
+	print.Flags().BoolP("redact", "r", false, "redact secrets")
+
+	var config = &Command{Name: "config", Aliases: []string{"cfg"}, Short: "Inspect the configuration"}
+	config.AddCommand(print)
+	root.AddCommand(config)
+
+	return root
+}
+
+func TestCommand_Execute(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "sub command",
+			args: []string{"config", "print", "sql.dsn"},
+			want: []string{"app config print", "sql.dsn"},
+		},
+		{
+			name: "flags before sub command",
+			args: []string{"--config", "print", "config", "-r", "print", "sql", "--", "-x"},
+			want: []string{"app config print", "sql", "-x"},
+		},
+		{
+			name: "alias",
+			args: []string{"cfg", "print"},
+			want: []string{"app config print"},
+		},
+		{
+			name:    "unknown command",
+			args:    []string{"config", "dump"},
+			wantErr: ErrUnknownCommand,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			var root = newTestRoot(&got)
+			root.SetOutput(new(bytes.Buffer))
+
+			err := root.Execute(context.TODO(), tt.args)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Execute() args = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommand_Help(t *testing.T) {
+	var got []string
+	var root = newTestRoot(&got)
+	var out = new(bytes.Buffer)
+	root.SetOutput(out)
+
+	if err := root.Execute(context.TODO(), []string{"config", "--help"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"Inspect the configuration",
+		"app config [command]",
+		"Aliases:\n  config, cfg",
+		"Available Commands:\n  print   Print the effective configuration",
+		"Global Flags:\n      --config string",
+		`Use "app config [command] --help"`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Help() = %s\nwant contains %q", out.String(), want)
+		}
+	}
+}
+
+func TestCommand_HelpFlagDefined(t *testing.T) {
+	var host string
+	var serve = &Command{
+		Name: "serve",
+		Run: func(ctx context.Context, cmd *Command, args []string) error {
+			return nil
+		},
+	}
+	serve.Flags().StringVarP(&host, "host", "h", "", "listen host")
+
+	var manual bool
+	var docs = &Command{
+		Name: "docs",
+		Run: func(ctx context.Context, cmd *Command, args []string) error {
+			if !manual {
+				return ErrTest
+			}
+			return nil
+		},
+	}
+	docs.Flags().BoolVar(&manual, "help", false, "open the manual")
+
+	var root = &Command{Name: "app"}
+	root.AddCommand(serve, docs)
+	var out = new(bytes.Buffer)
+	root.SetOutput(out)
+
+	if err := root.Execute(context.TODO(), []string{"serve", "-h", "localhost"}); err != nil || host != "localhost" {
+		t.Errorf("Execute() host = %q, error = %v", host, err)
+	}
+	if err := root.Execute(context.TODO(), []string{"serve", "--help"}); err != nil || !strings.Contains(out.String(), "--help ") {
+		t.Errorf("Execute() error = %v, help = %s", err, out.String())
+	}
+	if err := root.Execute(context.TODO(), []string{"docs", "--help"}); err != nil {
+		t.Errorf("Execute() error = %v, want the command --help flag", err)
+	}
+}
+
+func TestCommand_Completion(t *testing.T) {
+	var a = New(WithNameOption("demo"))
+	var root = NewRootCommand(a)
+
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{
+			shell: "bash",
+			want:  []string{"complete -o default -F _demo_completions demo", `"demo config print"`, "--env"},
+		},
+		{
+			shell: "zsh",
+			want:  []string{"#compdef demo", "compdef _demo demo", `"demo completion zsh"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var out = new(bytes.Buffer)
+			root.SetOutput(out)
+
+			if err := root.Execute(context.TODO(), []string{"completion", tt.shell}); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("completion = %s\nwant contains %q", out.String(), want)
+				}
+			}
+
+			// check the script syntax when the shell exists.
+			path, err := exec.LookPath(tt.shell)
+			if err != nil {
+				return
+			}
+			cmd := exec.Command(path, "-n")
+			cmd.Stdin = out
+			if data, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("%s -n error = %v, %s", tt.shell, err, data)
+			}
+		})
+	}
+}
+
+func TestRootCommand(t *testing.T) {
+	defer environment.SetCurrent(environment.Current())
+
+	var a = New(WithNameOption("demo"))
+	var root = NewRootCommand(a)
+	var out = new(bytes.Buffer)
+	root.SetOutput(out)
+
+	if err := root.Execute(context.TODO(), []string{"--env", "prod", "version"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "demo dev") {
+		t.Errorf("version = %q", out.String())
+	}
+	if a.Environment() != environment.Production || environment.Current() != environment.Production {
+		t.Errorf("Environment() = %v, Current() = %v, want %v", a.Environment(), environment.Current(), environment.Production)
+	}
+
+	out.Reset()
+	if err := root.Execute(context.TODO(), []string{"healthcheck"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if out.String() != "ok\n" {
+		t.Errorf("healthcheck = %q", out.String())
+	}
+
+	if err := root.Execute(context.TODO(), []string{"config", "print"}); err != ErrNoConfig {
+		t.Errorf("Execute() error = %v, want %v", err, ErrNoConfig)
+	}
+}
+
+func TestConfigCommand_Explain(t *testing.T) {
+	config, err := configurer.New(
+		configurer.MapProvider{"sql": map[string]interface{}{"driverName": "sqlite", "dsn": "file::memory:"}},
+		configurer.MapProvider{"sql": map[string]interface{}{"driverName": "mysql", "dsn": "root:pass@/app"}},
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var root = NewRootCommand(New(WithNameOption("demo"), WithConfigOption(config)))
+	var out = new(bytes.Buffer)
+	root.SetOutput(out)
+
+	if err := root.Execute(context.TODO(), []string{"config", "explain", "sql.dsn"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want := "sql.dsn = ****** (configurer.MapProvider, layer 1)\n  shadows ****** (configurer.MapProvider, layer 0)\n"
+	if out.String() != want {
+		t.Errorf("explain = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	if err := root.Execute(context.TODO(), []string{"config", "explain", "sql.driverName"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want = "sql.driverName = mysql (configurer.MapProvider, layer 1)\n  shadows sqlite (configurer.MapProvider, layer 0)\n"
+	if out.String() != want {
+		t.Errorf("explain = %q, want %q", out.String(), want)
+	}
+
+	if err := root.Execute(context.TODO(), []string{"config", "explain", "sql.user"}); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Execute() error = %v, want %v", err, ErrUnknownKey)
+	}
+}
+
+func TestSecretCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var root = NewRootCommand(New(WithNameOption("demo")))
+	var out = new(bytes.Buffer)
+	root.SetOutput(out)
+	var execute = func(args ...string) string {
+		out.Reset()
+		if err := root.Execute(context.TODO(), args); err != nil {
+			t.Fatalf("Execute(%v) error = %v", args, err)
+		}
+		return strings.TrimSpace(out.String())
+	}
+
+	var oldKey, newKey = filepath.Join(dir, "old.key"), filepath.Join(dir, "new.key")
+	ioutil.WriteFile(oldKey, []byte(execute("secret", "keygen")), 0600)
+	ioutil.WriteFile(newKey, []byte(execute("secret", "keygen")), 0600)
+
+	var encrypted = execute("secret", "encrypt", "--key-file", oldKey, "root:s3cret@/app")
+	if !secret.IsEncrypted(encrypted) {
+		t.Fatalf("encrypt = %q", encrypted)
+	}
+
+	var path = filepath.Join(dir, "config.yaml")
+	ioutil.WriteFile(path, []byte("sql:\n  dsn: "+encrypted+"\n"), 0600)
+	if got := execute("secret", "rotate", "--old-key-file", oldKey, "--new-key-file", newKey, path); got != path+": 1 rotated" {
+		t.Errorf("rotate = %q", got)
+	}
+
+	os.Setenv(secret.KeyFileEnv, newKey)
+	defer os.Unsetenv(secret.KeyFileEnv)
+	config, err := configurer.New(file.NewProvider(path))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := config.String("sql.dsn"); got != "root:s3cret@/app" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestConfigCommand_PrintAndDiff(t *testing.T) {
+	defer environment.SetCurrent(environment.Current())
+
+	var configs = map[environment.Environment]configurer.MapProvider{
+		environment.Development: {"logger": map[string]interface{}{"level": "debug"}, "sql": map[string]interface{}{"dsn": "root:dev@/app"}},
+		environment.Production:  {"logger": map[string]interface{}{"level": "info"}, "sql": map[string]interface{}{"dsn": "root:prd@/app"}, "replicas": 2},
+	}
+	var a = New(WithNameOption("demo"), WithConfigLoaderOption(func(env environment.Environment) (configurer.Configurer, error) {
+		return configurer.New(configs[env])
+	}))
+	var root = NewRootCommand(a)
+	var out = new(bytes.Buffer)
+	root.SetOutput(out)
+
+	if err := root.Execute(context.TODO(), []string{"--env", "prod", "config", "print", "--format", "json"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := out.String(); !strings.Contains(got, `"level": "info"`) || !strings.Contains(got, `"dsn": "******"`) {
+		t.Errorf("print = %s", got)
+	}
+
+	out.Reset()
+	if err := root.Execute(context.TODO(), []string{"config", "diff", "dev", "prod"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want := "~ logger.level: debug -> info\n+ replicas: 2\n~ sql.dsn: ****** -> ******\n"
+	if out.String() != want {
+		t.Errorf("diff = %q, want %q", out.String(), want)
+	}
+
+	root = NewRootCommand(New())
+	if err := root.Execute(context.TODO(), []string{"config", "diff", "uat", "prod"}); !errors.Is(err, ErrNoConfigLoader) {
+		t.Errorf("Execute() error = %v, want %v", err, ErrNoConfigLoader)
+	}
+}
@@ -0,0 +1,413 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"go.uber.org/multierr"
+
+	"github.com/go-framework/configurer"
+	"github.com/go-framework/configurer/providers/file"
+	"github.com/go-framework/configurer/secret"
+	"github.com/go-framework/configurer/templates/environment"
+)
+
+// NewRootCommand returns the root command of the application binary with the
+// serve, migrate, config, secret, version, healthcheck and completion commands. The
+// --env flag and the flags of the registered components which implement
+// Flagger are added to the persistent flags, so components should be
+// registered before.
+func NewRootCommand(a *App) *Command {
+	var root = &Command{
+		Name:  a.Name(),
+		Short: a.Name() + " application",
+	}
+
+	environment.AddEnvironmentFlag(root.PersistentFlags(), &a.options.Environment)
+	for _, component := range a.Components() {
+		if v, ok := component.(Flagger); ok {
+			v.AddFlags(root.PersistentFlags())
+		}
+	}
+
+	root.AddCommand(
+		NewServeCommand(a),
+		NewMigrateCommand(a),
+		NewConfigCommand(a),
+		NewSecretCommand(),
+		NewVersionCommand(a),
+		NewHealthcheckCommand(a),
+		NewCompletionCommand(),
+	)
+
+	return root
+}
+
+// NewServeCommand returns the serve command which runs the application.
+func NewServeCommand(a *App) *Command {
+	return &Command{
+		Name:  "serve",
+		Short: "Run the application until a signal is received",
+		Run: func(ctx context.Context, cmd *Command, args []string) error {
+			return a.Run(ctx)
+		},
+	}
+}
+
+// NewMigrateCommand returns the migrate command which starts the components
+// and migrates those which implement Migrator in start order, and the status
+// sub command of those which implement MigrationReporter.
+func NewMigrateCommand(a *App) *Command {
+	var migrate = &Command{
+		Name:  "migrate",
+		Short: "Migrate the storage of the application components",
+	}
+	var dryRun = migrate.Flags().Bool("dry-run", false, "plan the migrations without applying them")
+	migrate.Run = func(ctx context.Context, cmd *Command, args []string) error {
+		if *dryRun {
+			ctx = NewDryRunContext(ctx)
+		}
+		return a.Exec(ctx, func(ctx context.Context) (errs error) {
+			for _, component := range a.Components() {
+				if v, ok := component.(Migrator); ok {
+					if err := v.Migrate(ctx); err != nil {
+						errs = multierr.Append(errs, fmt.Errorf("migrate %s: %w", component.Name(), err))
+					}
+				}
+			}
+			return
+		})
+	}
+
+	migrate.AddCommand(&Command{
+		Name:  "status",
+		Short: "Print the migration status of the application components",
+		Run: func(ctx context.Context, cmd *Command, args []string) error {
+			return a.Exec(ctx, func(ctx context.Context) (errs error) {
+				for _, component := range a.Components() {
+					if v, ok := component.(MigrationReporter); ok {
+						fmt.Fprintf(cmd.Out(), "%s:\n", component.Name())
+						if err := v.MigrationStatus(ctx, cmd.Out()); err != nil {
+							errs = multierr.Append(errs, fmt.Errorf("migrate status %s: %w", component.Name(), err))
+						}
+					}
+				}
+				return
+			})
+		},
+	})
+
+	return migrate
+}
+
+// NewConfigCommand returns the config command with the print, diff, explain,
+// schema and validate sub commands.
+func NewConfigCommand(a *App) *Command {
+	var config = &Command{
+		Name:  "config",
+		Short: "Inspect the application configuration",
+	}
+
+	var printCmd = &Command{
+		Name:  "print",
+		Short: "Print the effective configuration of the environment, secrets are masked",
+	}
+	var format = printCmd.Flags().StringP("format", "f", "yaml", "output format, like yaml, json or toml")
+	printCmd.Run = func(ctx context.Context, cmd *Command, args []string) error {
+		config, err := a.LoadConfig(a.Environment())
+		if err != nil {
+			return err
+		}
+		data, err := config.Dump(*format, configurer.WithSecretOption("", Config{}))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(cmd.Out(), strings.TrimRight(string(data), "\n"))
+		return err
+	}
+
+	config.AddCommand(printCmd, &Command{
+		Name:  "diff",
+		Short: "Compare the effective configuration of two environments, secrets are masked",
+		Usage: "<environment> <environment>",
+		Run: func(ctx context.Context, cmd *Command, args []string) error {
+			if len(args) != 2 {
+				return fmt.Errorf("%s: requires exactly two environments", cmd.Path())
+			}
+			var configs [2]configurer.Configurer
+			for i, arg := range args {
+				var env environment.Environment
+				if err := env.UnmarshalText([]byte(arg)); err != nil {
+					return err
+				}
+				config, err := a.LoadConfig(env)
+				if err != nil {
+					return err
+				}
+				configs[i] = config
+			}
+			for _, change := range configurer.Diff(configs[0], configs[1], configurer.WithSecretOption("", Config{})) {
+				switch {
+				case change.Old == nil:
+					fmt.Fprintf(cmd.Out(), "+ %s: %v\n", change.Key, change.New)
+				case change.New == nil:
+					fmt.Fprintf(cmd.Out(), "- %s: %v\n", change.Key, change.Old)
+				default:
+					fmt.Fprintf(cmd.Out(), "~ %s: %v -> %v\n", change.Key, change.Old, change.New)
+				}
+			}
+			return nil
+		},
+	}, &Command{
+		Name:  "explain",
+		Short: "Explain which provider supplied the value of a key, secrets are masked",
+		Usage: "<key>",
+		Run: func(ctx context.Context, cmd *Command, args []string) error {
+			if a.Config() == nil {
+				return ErrNoConfig
+			}
+			if len(args) != 1 {
+				return fmt.Errorf("%s: requires exactly one key", cmd.Path())
+			}
+			origin, ok := a.Config().Explain(args[0], configurer.WithSecretOption("", Config{}))
+			if !ok {
+				return fmt.Errorf("%w: %q", ErrUnknownKey, args[0])
+			}
+			fmt.Fprintf(cmd.Out(), "%s = %v (%s, layer %d)\n", origin.Key, origin.Value, origin.Provider, origin.Layer)
+			for i := len(origin.Shadowed) - 1; i >= 0; i-- {
+				var shadowed = origin.Shadowed[i]
+				fmt.Fprintf(cmd.Out(), "  shadows %v (%s, layer %d)\n", shadowed.Value, shadowed.Provider, shadowed.Layer)
+			}
+			return nil
+		},
+	}, &Command{
+		Name:  "schema",
+		Short: "Print the JSON Schema of the configuration",
+		Run: func(ctx context.Context, cmd *Command, args []string) error {
+			data, err := json.MarshalIndent(a.Schema(), "", "  ")
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.Out(), string(data))
+			return err
+		},
+	}, &Command{
+		Name:  "validate",
+		Short: "Validate configuration files against the JSON Schema",
+		Usage: "<file>...",
+		Run: func(ctx context.Context, cmd *Command, args []string) (errs error) {
+			if len(args) == 0 {
+				return fmt.Errorf("%s: requires at least one file", cmd.Path())
+			}
+			var s = a.Schema()
+			for _, path := range args {
+				// the file is validated as written, the references and the
+				// encrypted values are not resolved.
+				m, err := file.NewProvider(path).Read()
+				if err == nil {
+					err = s.Validate(m)
+				}
+				if err != nil {
+					for _, err := range multierr.Errors(err) {
+						errs = multierr.Append(errs, fmt.Errorf("%s: %w", path, err))
+					}
+					continue
+				}
+				fmt.Fprintf(cmd.Out(), "%s: ok\n", path)
+			}
+			return
+		},
+	})
+
+	return config
+}
+
+// NewSecretCommand returns the secret command with the keygen, encrypt and
+// rotate sub commands of the encrypted configuration values.
+func NewSecretCommand() *Command {
+	var command = &Command{
+		Name:  "secret",
+		Short: "Manage the encrypted configuration values",
+		Long: `Manage the encrypted configuration values.
+
+Values like ENC[AES256_GCM,...] are decrypted when the configuration is loaded,
+the key is read from $` + secret.KeyEnv + ` or from the keyfile of $` + secret.KeyFileEnv + `.`,
+	}
+
+	var encrypt = &Command{
+		Name:  "encrypt",
+		Short: "Encrypt a value, it is read from the standard input if not given",
+		Usage: "[value]",
+	}
+	var keyFile = encrypt.Flags().String("key-file", "", "keyfile of the encryption key")
+	encrypt.Run = func(ctx context.Context, cmd *Command, args []string) error {
+		if len(args) > 1 {
+			return fmt.Errorf("%s: accepts at most one value", cmd.Path())
+		}
+		c, err := loadCipher(*keyFile)
+		if err != nil {
+			return err
+		}
+		var value string
+		if len(args) == 1 {
+			value = args[0]
+		} else {
+			data, err := ioutil.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			value = strings.TrimRight(string(data), "\r\n")
+		}
+		encrypted, err := c.Encrypt(value)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(cmd.Out(), encrypted)
+		return err
+	}
+
+	var rotate = &Command{
+		Name:  "rotate",
+		Short: "Re-encrypt the values of configuration files with a new key",
+		Usage: "[flags] <file>...",
+	}
+	var oldKeyFile = rotate.Flags().String("old-key-file", "", "keyfile of the current key")
+	var newKeyFile = rotate.Flags().String("new-key-file", "", "keyfile of the new key")
+	rotate.Run = func(ctx context.Context, cmd *Command, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("%s: requires at least one file", cmd.Path())
+		}
+		if *newKeyFile == "" {
+			return fmt.Errorf("%s: requires --new-key-file", cmd.Path())
+		}
+		old, err := loadCipher(*oldKeyFile)
+		if err != nil {
+			return err
+		}
+		next, err := loadCipher(*newKeyFile)
+		if err != nil {
+			return err
+		}
+		for _, path := range args {
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			text, n, err := secret.Rotate(string(data), old, next)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if n > 0 {
+				if err := ioutil.WriteFile(path, []byte(text), info.Mode()); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(cmd.Out(), "%s: %d rotated\n", path, n)
+		}
+		return nil
+	}
+
+	command.AddCommand(&Command{
+		Name:  "keygen",
+		Short: "Generate a base64 encoded encryption key",
+		Run: func(ctx context.Context, cmd *Command, args []string) error {
+			key, err := secret.GenerateKey()
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.Out(), key)
+			return err
+		},
+	}, encrypt, rotate)
+
+	return command
+}
+
+// loadCipher returns the cipher of the keyfile, the key is loaded from the
+// environment if the path is empty.
+func loadCipher(path string) (*secret.Cipher, error) {
+	var (
+		key []byte
+		err error
+	)
+	if path != "" {
+		key, err = secret.ReadKeyFile(path)
+	} else {
+		key, err = secret.LoadKey()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return secret.NewCipher(key)
+}
+
+// NewVersionCommand returns the version command which prints the build information.
+func NewVersionCommand(a *App) *Command {
+	return &Command{
+		Name:  "version",
+		Short: "Print the version information",
+		Run: func(ctx context.Context, cmd *Command, args []string) error {
+			_, err := fmt.Fprintln(cmd.Out(), VersionString(a.Name()))
+			return err
+		},
+	}
+}
+
+// NewHealthcheckCommand returns the healthcheck command which starts the
+// components and checks their health, it fails when any check fails.
+func NewHealthcheckCommand(a *App) *Command {
+	return &Command{
+		Name:  "healthcheck",
+		Short: "Check the health of the application components",
+		Run: func(ctx context.Context, cmd *Command, args []string) error {
+			if err := a.Exec(ctx, a.Health); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintln(cmd.Out(), "ok")
+			return err
+		},
+	}
+}
+
+// NewCompletionCommand returns the completion command which generates the
+// bash and zsh completion scripts of the root command.
+func NewCompletionCommand() *Command {
+	var completion = &Command{
+		Name:  "completion",
+		Short: "Generate the shell completion script",
+		Long: `Generate the shell completion script.
+
+To load the bash completion in the current shell:
+  source <(app completion bash)
+
+To load the zsh completion, write it into a directory of $fpath:
+  app completion zsh > "${fpath[1]}/_app"`,
+	}
+
+	completion.AddCommand(
+		&Command{
+			Name:  "bash",
+			Short: "Generate the bash completion script",
+			Run: func(ctx context.Context, cmd *Command, args []string) error {
+				return cmd.Root().GenBashCompletion(cmd.Out())
+			},
+		},
+		&Command{
+			Name:  "zsh",
+			Short: "Generate the zsh completion script",
+			Run: func(ctx context.Context, cmd *Command, args []string) error {
+				return cmd.Root().GenZshCompletion(cmd.Out())
+			},
+		},
+	)
+
+	return completion
+}
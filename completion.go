@@ -0,0 +1,144 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// GenBashCompletion writes the bash completion script of the command tree.
+func (c *Command) GenBashCompletion(w io.Writer) error {
+	var (
+		name = c.Root().Name
+		fn   = "_" + identifier(name) + "_completions"
+		b    strings.Builder
+	)
+
+	fmt.Fprintf(&b, "# bash completion for %s\n", name)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local cur cmdpath i words\n")
+	b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(&b, "    cmdpath=%q\n", name)
+	b.WriteString("    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	b.WriteString("        case \"${cmdpath} ${COMP_WORDS[i]}\" in\n")
+	if paths := c.Root().paths(); len(paths) > 0 {
+		fmt.Fprintf(&b, "            %s)\n", quoteAll(paths))
+		b.WriteString("                cmdpath=\"${cmdpath} ${COMP_WORDS[i]}\"\n")
+		b.WriteString("                ;;\n")
+	}
+	b.WriteString("        esac\n")
+	b.WriteString("    done\n")
+	b.WriteString("    case \"${cmdpath}\" in\n")
+	c.Root().walk(func(cmd *Command) {
+		fmt.Fprintf(&b, "        %q)\n", cmd.Path())
+		fmt.Fprintf(&b, "            words=%q\n", strings.Join(cmd.words(), " "))
+		b.WriteString("            ;;\n")
+	})
+	b.WriteString("    esac\n")
+	b.WriteString("    COMPREPLY=($(compgen -W \"${words}\" -- \"${cur}\"))\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "complete -o default -F %s %s\n", fn, name)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// GenZshCompletion writes the zsh completion script of the command tree.
+func (c *Command) GenZshCompletion(w io.Writer) error {
+	var (
+		name = c.Root().Name
+		fn   = "_" + identifier(name)
+		b    strings.Builder
+	)
+
+	fmt.Fprintf(&b, "#compdef %s\n\n", name)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local cmdpath i\n")
+	b.WriteString("    local -a candidates\n")
+	fmt.Fprintf(&b, "    cmdpath=%q\n", name)
+	b.WriteString("    for ((i = 2; i < CURRENT; i++)); do\n")
+	b.WriteString("        case \"${cmdpath} ${words[i]}\" in\n")
+	if paths := c.Root().paths(); len(paths) > 0 {
+		fmt.Fprintf(&b, "            %s)\n", quoteAll(paths))
+		b.WriteString("                cmdpath=\"${cmdpath} ${words[i]}\"\n")
+		b.WriteString("                ;;\n")
+	}
+	b.WriteString("        esac\n")
+	b.WriteString("    done\n")
+	b.WriteString("    case \"${cmdpath}\" in\n")
+	c.Root().walk(func(cmd *Command) {
+		fmt.Fprintf(&b, "        %q)\n", cmd.Path())
+		fmt.Fprintf(&b, "            candidates=(%s)\n", strings.Join(cmd.words(), " "))
+		b.WriteString("            ;;\n")
+	})
+	b.WriteString("    esac\n")
+	b.WriteString("    compadd -- \"${candidates[@]}\"\n")
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "compdef %s %s\n", fn, name)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// walk calls f with the command and its visible descendants in depth-first order.
+func (c *Command) walk(f func(cmd *Command)) {
+	f(c)
+	for _, command := range c.visibleCommands() {
+		command.walk(f)
+	}
+}
+
+// paths returns the paths of all visible descendants, including aliases.
+func (c *Command) paths() []string {
+	var list []string
+	c.walk(func(cmd *Command) {
+		if cmd.parent == nil {
+			return
+		}
+		for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+			list = append(list, cmd.parent.Path()+" "+name)
+		}
+	})
+	return list
+}
+
+// words returns the completion candidates of the command, sub command names and flags.
+func (c *Command) words() []string {
+	var list []string
+	for _, command := range c.visibleCommands() {
+		list = append(list, command.Name)
+	}
+
+	var fs = c.LocalFlags()
+	fs.AddFlagSet(c.InheritedFlags())
+	fs.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+		list = append(list, "--"+flag.Name)
+	})
+	if fs.Lookup("help") == nil {
+		list = append(list, "--help")
+	}
+
+	return list
+}
+
+var _nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// identifier returns a shell function name safe string.
+func identifier(name string) string {
+	return _nonIdentifier.ReplaceAllString(name, "_")
+}
+
+// quoteAll returns the case pattern of the quoted strings.
+func quoteAll(list []string) string {
+	var quoted = make([]string, len(list))
+	for i, item := range list {
+		quoted[i] = fmt.Sprintf("%q", item)
+	}
+	return strings.Join(quoted, "|")
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Component is a part of the application which follows the App lifecycle.
+// Components are initialized and started in dependency order, see Dependent,
+// then registration order, and stopped in reverse start order.
+type Component interface {
+	// Name returns the unique component name.
+	Name() string
+	// Init prepares the component, it is called once before any component starts.
+	Init(ctx context.Context, app *App) error
+	// Start starts the component, it should not block.
+	Start(ctx context.Context) error
+	// Stop stops the component and releases its resources.
+	Stop(ctx context.Context) error
+}
+
+// StopTimeouter is implemented by components which need their own stop deadline.
+// The deadline set by WithComponentStopTimeoutOption takes precedence.
+type StopTimeouter interface {
+	StopTimeout() time.Duration
+}
+
+// Dependent is implemented by components which depend on other components,
+// a component is initialized and started after all of its dependencies and
+// stopped before them. The built-in names LoggerName, ConfigName and EventName
+// are always satisfied by the App.
+type Dependent interface {
+	DependsOn() []string
+}
+
+// Built-in dependency names provided by the App.
+const (
+	LoggerName = "logger"
+	ConfigName = "config"
+	EventName  = "event"
+)
+
+// dependencies returns the component dependencies, nil when it is not a Dependent.
+func dependencies(component Component) []string {
+	if v, ok := component.(Dependent); ok {
+		return v.DependsOn()
+	}
+	return nil
+}
+
+// HealthChecker is implemented by components which report their health.
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// Migrator is implemented by components which migrate their storage, it is
+// called by the migrate command after the components started.
+type Migrator interface {
+	Migrate(ctx context.Context) error
+}
+
+// MigrationReporter is implemented by Migrators which report the status of
+// their migrations, it is called by the migrate status command.
+type MigrationReporter interface {
+	MigrationStatus(ctx context.Context, w io.Writer) error
+}
+
+// dryRunKey is the context key of the dry run.
+type dryRunKey struct{}
+
+// NewDryRunContext returns the context of the migrate --dry-run flag, the
+// Migrators should plan their migrations without applying them.
+func NewDryRunContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// GetDryRunFromContext reports whether the context is a dry run.
+func GetDryRunFromContext(ctx context.Context) bool {
+	v, _ := ctx.Value(dryRunKey{}).(bool)
+	return v
+}
+
+// Flagger is implemented by components which add their flags to the root
+// command persistent flags.
+type Flagger interface {
+	AddFlags(flag *pflag.FlagSet)
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/go-framework/configurer"
+	"github.com/go-framework/configurer/providers/file"
+	"github.com/go-framework/configurer/templates/environment"
+	"github.com/go-framework/event/inapp"
+)
+
+const (
+	// ConfigChangedEvent is published on the application event bus with the
+	// []configurer.Change after the configuration is reloaded.
+	ConfigChangedEvent = "config.changed"
+	// LoggerLevelKey is the configuration key followed by the options Level.
+	LoggerLevelKey = "logger.level"
+)
+
+// ConfigLoader loads the configuration of the environment.
+type ConfigLoader func(env environment.Environment) (configurer.Configurer, error)
+
+// NewFileConfigLoader returns the ConfigLoader of the file and its overlays of
+// the environment, like config.yaml, config.prod.yaml then config.local.yaml.
+// The providers override the files, like the environment variables and flags.
+func NewFileConfigLoader(path string, providers ...configurer.Provider) ConfigLoader {
+	return func(env environment.Environment) (configurer.Configurer, error) {
+		return configurer.New(append(file.NewOverlayProviders(path, env), providers...)...)
+	}
+}
+
+// LoadConfig loads the configuration of the environment by the options
+// Loader, the application configuration is returned for the application
+// environment when there is no loader.
+func (a *App) LoadConfig(env environment.Environment) (configurer.Configurer, error) {
+	if a.options.Loader != nil {
+		return a.options.Loader(env)
+	}
+	if env != a.Environment() {
+		return nil, fmt.Errorf("%w: %s", ErrNoConfigLoader, env)
+	}
+	if a.Config() == nil {
+		return nil, ErrNoConfig
+	}
+	return a.Config(), nil
+}
+
+// watch the configuration while the application is running, the changes are
+// published as ConfigChangedEvent and the logger level follows LoggerLevelKey.
+// It returns the func to stop watching.
+func (a *App) watch(ctx context.Context) (stop func()) {
+	var config = a.Config()
+	if config == nil {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	var unsubscribes []func()
+	if event := a.Event(); event != nil {
+		unsubscribes = append(unsubscribes, config.Subscribe("", func(changes []configurer.Change) {
+			// the changes are not published without subscriber.
+			if err := event.Publish(ctx, ConfigChangedEvent, changes); err != nil && !errors.Is(err, inapp.ErrNotExistEvent) {
+				a.Logger().Warn("publish config changes", zap.Error(err))
+			}
+		}))
+	}
+	if a.options.Level != (zap.AtomicLevel{}) {
+		unsubscribes = append(unsubscribes, config.Subscribe(LoggerLevelKey, a.setLevel))
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err := config.Watch(ctx, configurer.WithErrorHandlerOption(func(err error) {
+			a.Logger().Error("reload config", zap.Error(err))
+		}))
+		if err != nil {
+			a.Logger().Error("watch config", zap.Error(err))
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}
+}
+
+// setLevel set the options Level by the changes of LoggerLevelKey.
+func (a *App) setLevel(changes []configurer.Change) {
+	for _, change := range changes {
+		if change.Key != LoggerLevelKey || change.New == nil {
+			continue
+		}
+		var level = a.options.Level.Level()
+		if err := level.UnmarshalText([]byte(a.Config().String(LoggerLevelKey))); err != nil {
+			a.Logger().Warn("set logger level", zap.Error(err))
+			continue
+		}
+		a.options.Level.SetLevel(level)
+		a.Logger().Info("logger level changed", zap.Stringer("level", level))
+	}
+}
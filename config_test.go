@@ -0,0 +1,228 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/natefinch/lumberjack"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/go-framework/configurer"
+	"github.com/go-framework/configurer/providers/file"
+	"github.com/go-framework/configurer/templates/environment"
+	"github.com/go-framework/event/inapp"
+	"github.com/go-framework/logger"
+)
+
+func TestConfig_LoggerTemplates(t *testing.T) {
+	paths, err := filepath.Glob("vendor/github.com/go-framework/logger/templates/*")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("Glob() = %v, error = %v", paths, err)
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			config, err := configurer.New(file.NewProvider(path))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			var cfg = logger.NewDevelopmentConfig()
+			if err := config.Unmarshal("", &cfg); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if len(cfg.Writes) == 0 || cfg.Writes[0].Writer == nil {
+				t.Fatalf("Unmarshal() writes = %+v", cfg.Writes)
+			}
+			if cfg.Writes[0].Name == "lumberjack" {
+				if w, ok := cfg.Writes[0].Writer.(*lumberjack.Logger); !ok || w.MaxSize != 1024 {
+					t.Errorf("Unmarshal() writer = %+v", cfg.Writes[0].Writer)
+				}
+			}
+			if cfg.EncoderConfig.MessageKey != "M" {
+				t.Errorf("Unmarshal() encoderConfig = %+v", cfg.EncoderConfig)
+			}
+
+			log, err := cfg.Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			defer log.Sync()
+		})
+	}
+
+	if _, err := configurer.New(file.NewProvider("config.ini")); !errors.Is(err, file.ErrUnsupportedExtension) {
+		t.Errorf("New() error = nil, want %v", file.ErrUnsupportedExtension)
+	}
+}
+
+func TestApp_WatchConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(path, []byte("logger:\n  level: info\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := configurer.New(file.NewProvider(path, file.WithIntervalOption(10*time.Millisecond)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var (
+		level   = zap.NewAtomicLevelAt(zap.InfoLevel)
+		changed = make(chan []configurer.Change, 1)
+		a       = New(WithConfigOption(config), WithLevelOption(level), WithSignalsOption())
+	)
+	a.Event().Subscribe(context.TODO(), ConfigChangedEvent, func(ctx context.Context, args ...interface{}) error {
+		changed <- args[0].([]configurer.Change)
+		return nil
+	})
+
+	err = a.Exec(context.TODO(), func(ctx context.Context) error {
+		time.Sleep(30 * time.Millisecond)
+		if err := ioutil.WriteFile(path, []byte("logger:\n  level: debug\n"), 0644); err != nil {
+			return err
+		}
+		select {
+		case changes := <-changed:
+			want := []configurer.Change{{Key: LoggerLevelKey, Old: "info", New: "debug"}}
+			if !reflect.DeepEqual(changes, want) {
+				t.Errorf("changes = %v, want %v", changes, want)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("config changed event timeout")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if level.Level() != zap.DebugLevel {
+		t.Errorf("Level() = %v, want %v", level.Level(), zap.DebugLevel)
+	}
+}
+
+func TestApp_WatchConfigWithoutSubscriber(t *testing.T) {
+	dir, err := ioutil.TempDir("", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, event := range []*inapp.Event{inapp.NewEvent(), nil} {
+		var path = filepath.Join(dir, fmt.Sprintf("config%d.yaml", i))
+		if err := ioutil.WriteFile(path, []byte("logger:\n  level: info\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		config, err := configurer.New(file.NewProvider(path, file.WithIntervalOption(10*time.Millisecond)))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		var (
+			core, logs = observer.New(zap.WarnLevel)
+			level      = zap.NewAtomicLevelAt(zap.InfoLevel)
+			a          = New(WithConfigOption(config), WithLevelOption(level), WithEventOption(event),
+				WithLoggerOption(zap.New(core)), WithSignalsOption())
+		)
+		err = a.Exec(context.TODO(), func(ctx context.Context) error {
+			time.Sleep(30 * time.Millisecond)
+			if err := ioutil.WriteFile(path, []byte("logger:\n  level: debug\n"), 0644); err != nil {
+				return err
+			}
+			for i := 0; i < 100 && level.Level() != zap.DebugLevel; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Exec() error = %v", err)
+		}
+		if level.Level() != zap.DebugLevel {
+			t.Errorf("Level() = %v, want %v", level.Level(), zap.DebugLevel)
+		}
+		if logs.Len() != 0 {
+			t.Errorf("logs = %v, want none", logs.All())
+		}
+	}
+}
+
+func TestConfig_IncludeAndInterpolate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	template, err := filepath.Abs("vendor/github.com/go-framework/logger/templates/lumberjack.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var path = filepath.Join(dir, "config.yaml")
+	ioutil.WriteFile(path, []byte(`
+name: orders
+logger:
+  include: `+template+`
+  level: ${LOG_LEVEL:-warn}
+sql:
+  driverName: mysql
+  dsn: root@tcp(${DB_HOST:-localhost}:3306)/${name}
+`), 0644)
+
+	os.Setenv("DB_HOST", "db")
+	defer os.Unsetenv("DB_HOST")
+
+	config, err := configurer.New(file.NewProvider(path))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var cfg Config
+	if err := config.Unmarshal("", &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if cfg.SQL.DSN != "root@tcp(db:3306)/orders" {
+		t.Errorf("Unmarshal() sql.dsn = %q", cfg.SQL.DSN)
+	}
+	if cfg.Logger.Level.String() != "warn" || len(cfg.Logger.Writes) != 1 || cfg.Logger.Writes[0].Name != "lumberjack" {
+		t.Errorf("Unmarshal() logger = %+v", cfg.Logger)
+	}
+}
+
+func TestNewFileConfigLoader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "config.yaml")
+	ioutil.WriteFile(path, []byte("logger:\n  level: info\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "config.uat.yaml"), []byte("logger:\n  level: warn\n"), 0644)
+
+	var load = NewFileConfigLoader(path, configurer.MapProvider{"name": "orders"})
+	for env, want := range map[environment.Environment]string{
+		environment.Development:        "info",
+		environment.UserAcceptanceTest: "warn",
+	} {
+		config, err := load(env)
+		if err != nil {
+			t.Fatalf("load(%v) error = %v", env, err)
+		}
+		if got := config.String(LoggerLevelKey); got != want || config.String("name") != "orders" {
+			t.Errorf("load(%v) = %v, want level %s", env, config.All(), want)
+		}
+	}
+}
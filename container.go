@@ -0,0 +1,457 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Scope of the provided instance.
+type Scope int8
+
+const (
+	Singleton Scope = iota // The constructor is called once and the instance is shared.
+	Transient              // The constructor is called at every resolve.
+)
+
+// String returns a lower-case ASCII representation of the scope.
+func (s Scope) String() string {
+	switch s {
+	case Singleton:
+		return "singleton"
+	case Transient:
+		return "transient"
+	default:
+		return fmt.Sprintf("Scope(%d)", s)
+	}
+}
+
+// In can be embedded into a struct to mark it as a parameter object, each
+// exported field of the struct is resolved from the container. The field tag
+// `name:"..."` selects a named instance and `optional:"true"` leaves the zero
+// value when the provider is missing.
+//
+// For example,
+//
+//	type Params struct {
+//	  app.In
+//	  Primary *gorm.DB `name:"primary"`
+//	  Replica *gorm.DB `name:"replica" optional:"true"`
+//	}
+type In struct{}
+
+var (
+	_inType    = reflect.TypeOf(In{})
+	_errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Provide option func.
+type ProvideOption func(options *ProvideOptions)
+
+// Provide options.
+type ProvideOptions struct {
+	Name  string // Name of the instance, empty is the default instance of the type.
+	Scope Scope  // Scope of the instance, default is Singleton.
+}
+
+// Get default ProvideOptions value.
+func GetDefaultProvideOptions() *ProvideOptions {
+	opts := &ProvideOptions{
+		Scope: Singleton,
+	}
+	return opts
+}
+
+// WithInstanceNameOption provides a named instance.
+func WithInstanceNameOption(name string) ProvideOption {
+	return func(options *ProvideOptions) {
+		options.Name = name
+	}
+}
+
+// WithScopeOption set the instance scope.
+func WithScopeOption(scope Scope) ProvideOption {
+	return func(options *ProvideOptions) {
+		options.Scope = scope
+	}
+}
+
+// ResolveError is returned when an instance can't be resolved, Chain is the
+// resolving path from the requested instance to the failed one.
+type ResolveError struct {
+	Chain []string
+	Err   error
+}
+
+func (e *ResolveError) Error() string {
+	return fmt.Sprintf("resolve %s: %v", strings.Join(e.Chain, " -> "), e.Err)
+}
+
+func (e *ResolveError) Unwrap() error {
+	return e.Err
+}
+
+// provider key, a type and an optional instance name.
+type key struct {
+	typ  reflect.Type
+	name string
+}
+
+func (k key) String() string {
+	if k.name == "" {
+		return k.typ.String()
+	}
+	return fmt.Sprintf("%s[%s]", k.typ, k.name)
+}
+
+// provider of an instance.
+type provider struct {
+	constructor reflect.Value
+	scope       Scope
+
+	mu       sync.Mutex    // mu protects the singleton construction.
+	done     bool          // done is true when value is constructed.
+	value    reflect.Value // value is the singleton instance.
+	supplied bool          // supplied instance without constructor.
+}
+
+// Container is a dependency injection container, it resolves constructors
+// lazily by their result types.
+type Container struct {
+	mu        sync.RWMutex // mu protects providers.
+	providers map[key]*provider
+}
+
+// New Container.
+func NewContainer() *Container {
+	return &Container{
+		providers: make(map[key]*provider),
+	}
+}
+
+// Provide a constructor, it must be a func which returns a value and an
+// optional error, like func(cfg sql.Config, log *logger.Logger) (*gorm.DB, error).
+// The parameters are resolved from the container when the instance is resolved.
+func (c *Container) Provide(constructor interface{}, opts ...ProvideOption) error {
+	var fn = reflect.ValueOf(constructor)
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		return fmt.Errorf("%w: %T is not a func", ErrInvalidConstructor, constructor)
+	}
+
+	var typ = fn.Type()
+	switch {
+	case typ.NumOut() == 1 && typ.Out(0) != _errorType:
+	case typ.NumOut() == 2 && typ.Out(0) != _errorType && typ.Out(1) == _errorType:
+	default:
+		return fmt.Errorf("%w: %s must return a value and an optional error", ErrInvalidConstructor, typ)
+	}
+	if typ.IsVariadic() {
+		return fmt.Errorf("%w: %s is variadic", ErrInvalidConstructor, typ)
+	}
+
+	return c.add(typ.Out(0), &provider{constructor: fn}, opts...)
+}
+
+// Supply an existing instance, the instance type is used as the provider type.
+func (c *Container) Supply(value interface{}, opts ...ProvideOption) error {
+	if value == nil {
+		return fmt.Errorf("%w: nil value", ErrInvalidConstructor)
+	}
+
+	var v = reflect.ValueOf(value)
+	return c.add(v.Type(), &provider{done: true, value: v, supplied: true}, opts...)
+}
+
+// SupplyAs supply an existing instance as the type pointed by ptr, it is used to
+// provide an interface type, like c.SupplyAs((*configurer.Configurer)(nil), cfg).
+func (c *Container) SupplyAs(ptr interface{}, value interface{}, opts ...ProvideOption) error {
+	var typ = reflect.TypeOf(ptr)
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		return fmt.Errorf("%w: %T is not a pointer", ErrInvalidConstructor, ptr)
+	}
+	var v = reflect.ValueOf(value)
+	if !v.IsValid() || !v.Type().AssignableTo(typ.Elem()) {
+		return fmt.Errorf("%w: %T is not assignable to %s", ErrInvalidConstructor, value, typ.Elem())
+	}
+
+	var instance = reflect.New(typ.Elem()).Elem()
+	instance.Set(v)
+	return c.add(typ.Elem(), &provider{done: true, value: instance, supplied: true}, opts...)
+}
+
+func (c *Container) add(typ reflect.Type, p *provider, opts ...ProvideOption) error {
+	var options = GetDefaultProvideOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	p.scope = options.Scope
+
+	var k = key{typ: typ, name: options.Name}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.providers[k]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateProvider, k)
+	}
+	c.providers[k] = p
+
+	return nil
+}
+
+// Resolve the instance into the value pointed by ptr, the optional name selects
+// a named instance.
+func (c *Container) Resolve(ptr interface{}, name ...string) error {
+	var v = reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("%w: %T is not a pointer", ErrInvalidConstructor, ptr)
+	}
+
+	var k = key{typ: v.Type().Elem()}
+	if len(name) > 0 {
+		k.name = name[0]
+	}
+
+	if err := c.checkCycle(k, nil, make(map[key]bool)); err != nil {
+		return err
+	}
+	value, err := c.resolve(k, nil)
+	if err != nil {
+		return err
+	}
+	v.Elem().Set(value)
+
+	return nil
+}
+
+// Invoke the func with parameters resolved from the container, the func error
+// result is returned if any.
+func (c *Container) Invoke(fn interface{}) error {
+	var f = reflect.ValueOf(fn)
+	if f.Kind() != reflect.Func || f.IsNil() {
+		return fmt.Errorf("%w: %T is not a func", ErrInvalidConstructor, fn)
+	}
+
+	var checked = make(map[key]bool)
+	for _, k := range paramKeys(f.Type()) {
+		if err := c.checkCycle(k, nil, checked); err != nil {
+			return err
+		}
+	}
+	args, err := c.args(f.Type(), nil)
+	if err != nil {
+		return err
+	}
+
+	for _, out := range f.Call(args) {
+		if out.Type() == _errorType && !out.IsNil() {
+			return out.Interface().(error)
+		}
+	}
+
+	return nil
+}
+
+// Has reports whether the container has the provider of the type pointed by ptr.
+func (c *Container) Has(ptr interface{}, name ...string) bool {
+	var typ = reflect.TypeOf(ptr)
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		return false
+	}
+	var k = key{typ: typ.Elem()}
+	if len(name) > 0 {
+		k.name = name[0]
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, ok := c.providers[k]
+	return ok
+}
+
+func (c *Container) resolve(k key, chain []key) (reflect.Value, error) {
+	chain = append(chain, k)
+
+	for _, item := range chain[:len(chain)-1] {
+		if item == k {
+			return reflect.Value{}, &ResolveError{Chain: chainNames(chain), Err: ErrProviderCycle}
+		}
+	}
+
+	c.mu.RLock()
+	p, ok := c.providers[k]
+	c.mu.RUnlock()
+	if !ok {
+		return reflect.Value{}, &ResolveError{Chain: chainNames(chain), Err: ErrMissingProvider}
+	}
+
+	if p.supplied {
+		return p.value, nil
+	}
+
+	if p.scope == Transient {
+		return c.construct(p, chain)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.done {
+		return p.value, nil
+	}
+
+	value, err := c.construct(p, chain)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	p.value, p.done = value, true
+
+	return value, nil
+}
+
+// checkCycle reports the provider cycle from k by the parameters of the
+// constructors before any singleton is locked, so resolving a cycle from
+// its opposite ends concurrently fails instead of deadlocking. The keys
+// without cycle are checked.
+func (c *Container) checkCycle(k key, chain []key, checked map[key]bool) error {
+	chain = append(chain, k)
+
+	for _, item := range chain[:len(chain)-1] {
+		if item == k {
+			return &ResolveError{Chain: chainNames(chain), Err: ErrProviderCycle}
+		}
+	}
+	if checked[k] {
+		return nil
+	}
+
+	c.mu.RLock()
+	p, ok := c.providers[k]
+	c.mu.RUnlock()
+
+	if ok && !p.supplied {
+		for _, dep := range paramKeys(p.constructor.Type()) {
+			if err := c.checkCycle(dep, chain, checked); err != nil {
+				return err
+			}
+		}
+	}
+	checked[k] = true
+
+	return nil
+}
+
+// paramKeys returns the keys of the parameters of the func type, the
+// fields of the parameter objects are included.
+func paramKeys(typ reflect.Type) []key {
+	var keys []key
+	for i := 0; i < typ.NumIn(); i++ {
+		var in = typ.In(i)
+		if !isParamObject(in) {
+			keys = append(keys, key{typ: in})
+			continue
+		}
+		for j := 0; j < in.NumField(); j++ {
+			var field = in.Field(j)
+			if field.Type == _inType || field.PkgPath != "" {
+				continue
+			}
+			keys = append(keys, key{typ: field.Type, name: field.Tag.Get("name")})
+		}
+	}
+	return keys
+}
+
+// construct calls the provider constructor with resolved parameters.
+func (c *Container) construct(p *provider, chain []key) (reflect.Value, error) {
+	args, err := c.args(p.constructor.Type(), chain)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	var out = p.constructor.Call(args)
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, &ResolveError{Chain: chainNames(chain), Err: out[1].Interface().(error)}
+	}
+
+	return out[0], nil
+}
+
+// args resolves the parameters of the func type.
+func (c *Container) args(typ reflect.Type, chain []key) ([]reflect.Value, error) {
+	var args = make([]reflect.Value, typ.NumIn())
+	for i := range args {
+		var in = typ.In(i)
+
+		if isParamObject(in) {
+			value, err := c.paramObject(in, chain)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = value
+			continue
+		}
+
+		value, err := c.resolve(key{typ: in}, chain)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	return args, nil
+}
+
+// isParamObject reports whether the type is a struct embedding In.
+func isParamObject(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		if field := typ.Field(i); field.Anonymous && field.Type == _inType {
+			return true
+		}
+	}
+	return false
+}
+
+// paramObject resolves each exported field of the parameter object.
+func (c *Container) paramObject(typ reflect.Type, chain []key) (reflect.Value, error) {
+	var value = reflect.New(typ).Elem()
+	for i := 0; i < typ.NumField(); i++ {
+		var field = typ.Field(i)
+		if field.Type == _inType || field.PkgPath != "" {
+			continue
+		}
+
+		v, err := c.resolve(key{typ: field.Type, name: field.Tag.Get("name")}, chain)
+		if err != nil {
+			if field.Tag.Get("optional") == "true" && isMissing(err, key{typ: field.Type, name: field.Tag.Get("name")}) {
+				continue
+			}
+			return reflect.Value{}, err
+		}
+		value.Field(i).Set(v)
+	}
+	return value, nil
+}
+
+// isMissing reports whether the error is the missing provider of k itself and
+// not one of its dependencies.
+func isMissing(err error, k key) bool {
+	var e *ResolveError
+	if !errors.As(err, &e) || !errors.Is(e.Err, ErrMissingProvider) {
+		return false
+	}
+	return e.Chain[len(e.Chain)-1] == k.String()
+}
+
+func chainNames(chain []key) []string {
+	var names = make([]string, len(chain))
+	for i, k := range chain {
+		names[i] = k.String()
+	}
+	return names
+}
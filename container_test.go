@@ -0,0 +1,189 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-framework/configurer/templates/sql"
+	"github.com/go-framework/event/inapp"
+	"github.com/go-framework/logger"
+)
+
+type testDB struct {
+	cfg sql.Config
+	log *logger.Logger
+}
+
+type testHandler struct {
+	db *testDB
+}
+
+func TestContainer_Resolve(t *testing.T) {
+	var (
+		c     = NewContainer()
+		calls int
+	)
+
+	c.Supply(sql.Config{DriverName: "sqlite"})
+	c.Supply(logger.DefaultLogger)
+	c.Provide(func(cfg sql.Config, log *logger.Logger) (*testDB, error) {
+		calls++
+		return &testDB{cfg: cfg, log: log}, nil
+	})
+	c.Provide(func(db *testDB) *testHandler {
+		return &testHandler{db: db}
+	}, WithScopeOption(Transient))
+
+	var h1, h2 *testHandler
+	if err := c.Resolve(&h1); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if err := c.Resolve(&h2); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	if h1 == h2 {
+		t.Errorf("Resolve() transient instances are the same")
+	}
+	if h1.db != h2.db || calls != 1 {
+		t.Errorf("Resolve() singleton is constructed %d times", calls)
+	}
+	if h1.db.cfg.DriverName != "sqlite" || h1.db.log != logger.DefaultLogger {
+		t.Errorf("Resolve() db = %+v", h1.db)
+	}
+}
+
+func TestContainer_Named(t *testing.T) {
+	var c = NewContainer()
+
+	c.Supply(sql.Config{DriverName: "mysql"}, WithInstanceNameOption("primary"))
+	c.Supply(sql.Config{DriverName: "sqlite"}, WithInstanceNameOption("replica"))
+
+	type params struct {
+		In
+		Primary   sql.Config `name:"primary"`
+		Replica   sql.Config `name:"replica"`
+		Analytics sql.Config `name:"analytics" optional:"true"`
+	}
+
+	var got params
+	err := c.Invoke(func(p params) {
+		got = p
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if got.Primary.DriverName != "mysql" || got.Replica.DriverName != "sqlite" || got.Analytics.DriverName != "" {
+		t.Errorf("Invoke() params = %+v", got)
+	}
+
+	var cfg sql.Config
+	if err := c.Resolve(&cfg, "replica"); err != nil || cfg.DriverName != "sqlite" {
+		t.Errorf("Resolve() = %+v, error = %v", cfg, err)
+	}
+}
+
+func TestContainer_Errors(t *testing.T) {
+	var c = NewContainer()
+
+	c.Provide(func(cfg sql.Config) (*testDB, error) {
+		return &testDB{cfg: cfg}, nil
+	})
+	c.Provide(func(db *testDB) *testHandler {
+		return &testHandler{db: db}
+	})
+
+	var h *testHandler
+	err := c.Resolve(&h)
+	if !errors.Is(err, ErrMissingProvider) {
+		t.Fatalf("Resolve() error = %v, want %v", err, ErrMissingProvider)
+	}
+	if want := "resolve *app.testHandler -> *app.testDB -> sql.Config: missing provider"; err.Error() != want {
+		t.Errorf("Resolve() error = %q, want %q", err.Error(), want)
+	}
+
+	c = NewContainer()
+	c.Provide(func(h *testHandler) *testDB {
+		return &testDB{}
+	})
+	c.Provide(func(db *testDB) *testHandler {
+		return &testHandler{db: db}
+	})
+
+	err = c.Resolve(&h)
+	if !errors.Is(err, ErrProviderCycle) {
+		t.Fatalf("Resolve() error = %v, want %v", err, ErrProviderCycle)
+	}
+	if want := "resolve *app.testHandler -> *app.testDB -> *app.testHandler: provider cycle"; err.Error() != want {
+		t.Errorf("Resolve() error = %q, want %q", err.Error(), want)
+	}
+
+	c = NewContainer()
+	c.Provide(func() (*testDB, error) {
+		return nil, ErrTest
+	})
+	var db *testDB
+	if err := c.Resolve(&db); !errors.Is(err, ErrTest) {
+		t.Errorf("Resolve() error = %v, want %v", err, ErrTest)
+	}
+	if err := c.Provide(func() (*testDB, error) { return nil, nil }); !errors.Is(err, ErrDuplicateProvider) {
+		t.Errorf("Provide() error = %v, want %v", err, ErrDuplicateProvider)
+	}
+	if err := c.Provide(func() error { return nil }); !errors.Is(err, ErrInvalidConstructor) {
+		t.Errorf("Provide() error = %v, want %v", err, ErrInvalidConstructor)
+	}
+}
+
+func TestContainer_ConcurrentCycle(t *testing.T) {
+	// the slow parameters hold each singleton while the other end is locked.
+	var c = NewContainer()
+	c.Provide(func() int {
+		time.Sleep(10 * time.Millisecond)
+		return 1
+	})
+	c.Provide(func() string {
+		time.Sleep(10 * time.Millisecond)
+		return "a"
+	})
+	c.Provide(func(n int, h *testHandler) *testDB {
+		return &testDB{}
+	})
+	c.Provide(func(s string, db *testDB) *testHandler {
+		return &testHandler{db: db}
+	})
+
+	var errs = make(chan error, 2)
+	go func() {
+		var db *testDB
+		errs <- c.Resolve(&db)
+	}()
+	go func() {
+		var h *testHandler
+		errs <- c.Resolve(&h)
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, ErrProviderCycle) {
+				t.Fatalf("Resolve() error = %v, want %v", err, ErrProviderCycle)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Resolve() deadlock")
+		}
+	}
+}
+
+func TestApp_Container(t *testing.T) {
+	var a = New()
+
+	err := a.Container().Invoke(func(app *App, log *logger.Logger, event *inapp.Event) {
+		if app != a || log != a.Logger() || event != a.Event() {
+			t.Errorf("Invoke() got unexpected app instances")
+		}
+	})
+	if err != nil {
+		t.Errorf("Invoke() error = %v", err)
+	}
+}
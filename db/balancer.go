@@ -0,0 +1,41 @@
+package db
+
+import (
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// Balancer picks the database of a read among the healthy replicas, the
+// replicas are not empty.
+type Balancer func(replicas []*gorm.DB) *gorm.DB
+
+// RoundRobin returns the Balancer which picks the replicas in turn.
+func RoundRobin() Balancer {
+	var next uint32
+	return func(replicas []*gorm.DB) *gorm.DB {
+		var i = atomic.AddUint32(&next, 1) - 1
+		return replicas[int(i%uint32(len(replicas)))]
+	}
+}
+
+// LeastConnections returns the Balancer which picks the replica with the
+// least connections in use, the first one wins the ties.
+func LeastConnections() Balancer {
+	return func(replicas []*gorm.DB) *gorm.DB {
+		var (
+			picked = replicas[0]
+			least  = -1
+		)
+		for _, replica := range replicas {
+			sqlDB, err := replica.DB()
+			if err != nil {
+				continue
+			}
+			if inUse := sqlDB.Stats().InUse; least < 0 || inUse < least {
+				picked, least = replica, inUse
+			}
+		}
+		return picked
+	}
+}
@@ -0,0 +1,279 @@
+// Package db provides the database component which opens a *gorm.DB from the
+// sql.Config of the application configuration. The dialector of the driver
+// name should be registered, see RegisterDialector.
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/go-framework/app"
+	"github.com/go-framework/configurer"
+	"github.com/go-framework/configurer/templates/sql"
+	"github.com/go-framework/event/inapp"
+	"github.com/go-framework/logger"
+)
+
+var (
+	ErrUnknownDriver = errors.New("unknown database driver")
+	ErrNotOpen       = errors.New("database not open")
+)
+
+// Open the *gorm.DB of the config by the registered dialector of the driver
+// name, the zero pool settings are set to the defaults of sql.Config and the
+// statements are bounded by the StatementTimeout. A negative MaxIdleConns
+// retains no idle connections and a negative MaxOpenConns has no limit. It
+// does not ping the database. The SQL logs of the GormLogger are masked by
+// its options.
+func Open(config sql.Config, gormConfig *gorm.Config) (*gorm.DB, error) {
+	if err := configurer.SetDefaults(&config); err != nil {
+		return nil, err
+	}
+	dialector, ok := GetDialector(config.DriverName)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDriver, config.DriverName)
+	}
+
+	if gormConfig == nil {
+		gormConfig = &gorm.Config{}
+	}
+	var c = *gormConfig
+	c.DisableAutomaticPing = true
+
+	db, err := gorm.Open(dialector(config.DSN), &c)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+
+	if l, ok := c.Logger.(*GormLogger); ok && l.options.MaskParams {
+		if err := registerStatementContext(db); err != nil {
+			return nil, err
+		}
+	}
+	if config.StatementTimeout > 0 {
+		if err := registerStatementTimeout(db, config.StatementTimeout); err != nil {
+			return nil, err
+		}
+	}
+
+	return db, nil
+}
+
+// DB is the database component, it opens the database at start with ping
+// retries and closes it at stop.
+type DB struct {
+	name    string
+	options *Options
+	log     *logger.Logger
+	event   *inapp.Event // event of the migrate.AppliedEvent.
+
+	flags  *pflag.FlagSet // flags added by AddFlags.
+	config *sql.Config    // config is the options Config or the config decoded by Init.
+
+	mu sync.RWMutex // mu protects db.
+	db *gorm.DB
+}
+
+// New DB component of the name with options.
+func New(name string, opts ...Option) *DB {
+	options := GetDefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	var d = &DB{name: name, options: options}
+	if options.Config != nil {
+		var config = *options.Config
+		d.config = &config
+	}
+	return d
+}
+
+// Implement app.Component interface.
+func (d *DB) Name() string {
+	return d.name
+}
+
+// Implement app.Dependent interface.
+func (d *DB) DependsOn() []string {
+	return []string{app.ConfigName, app.LoggerName}
+}
+
+// Implement app.Flagger interface, the flags of the config are prefixed by
+// the component name, like --orders-db-dsn.
+func (d *DB) AddFlags(flag *pflag.FlagSet) {
+	sql.AddSqlConfigFlagsWithPrefix(flag, &sql.Config{}, d.name)
+	d.flags = flag
+}
+
+// Implement app.Component interface, the config is decoded from the
+// application configuration by the options Key when it is not set, then the
+// changed flags override a copy of it and it is validated.
+func (d *DB) Init(ctx context.Context, a *app.App) error {
+	d.log = a.Logger()
+	d.event = a.Event()
+
+	var config sql.Config
+	if d.options.Config != nil {
+		config = *d.options.Config
+	} else {
+		if a.Config() == nil {
+			return app.ErrNoConfig
+		}
+		if err := configurer.Decode(d.options.Key, a.Config().Get(d.options.Key), &config); err != nil {
+			return err
+		}
+	}
+	if err := d.setFlags(&config); err != nil {
+		return err
+	}
+	if err := configurer.ValidateKey(d.options.Key, &config); err != nil {
+		return err
+	}
+	d.config = &config
+	return nil
+}
+
+// setFlags sets the changed flags to the config.
+func (d *DB) setFlags(config *sql.Config) (errs error) {
+	if d.flags == nil {
+		return nil
+	}
+
+	// the flags may be parsed by another FlagSet which shares them, so the
+	// changed flags are set to the config by a FlagSet bound to it.
+	var fs = pflag.NewFlagSet(d.name, pflag.ContinueOnError)
+	sql.AddSqlConfigFlagsWithPrefix(fs, config, d.name)
+	fs.VisitAll(func(flag *pflag.Flag) {
+		if f := d.flags.Lookup(flag.Name); f != nil && f.Changed {
+			errs = multierr.Append(errs, fs.Set(flag.Name, f.Value.String()))
+		}
+	})
+	return
+}
+
+// Implement app.Component interface, the database is pinged with retries and
+// exponential backoff until it is available. The SQL is logged by a
+// GormLogger of the application logger when the Gorm Logger is nil.
+func (d *DB) Start(ctx context.Context) error {
+	if d.config == nil {
+		return app.ErrNoConfig
+	}
+	var gormConfig = d.options.Gorm
+	if (gormConfig == nil || gormConfig.Logger == nil) && d.log != nil {
+		var c gorm.Config
+		if gormConfig != nil {
+			c = *gormConfig
+		}
+		c.Logger = NewGormLogger(d.log, d.options.LoggerOptions...)
+		gormConfig = &c
+	}
+	db, err := Open(*d.config, gormConfig)
+	if err != nil {
+		return err
+	}
+	if err := d.ping(ctx, db); err != nil {
+		if sqlDB, e := db.DB(); e == nil {
+			sqlDB.Close()
+		}
+		return err
+	}
+
+	d.mu.Lock()
+	d.db = db
+	d.mu.Unlock()
+
+	return nil
+}
+
+// ping the database until it succeeds or the retries are exhausted, each ping
+// is bounded by the ConnectTimeout.
+func (d *DB) ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	var ping = func() error {
+		if timeout := d.config.ConnectTimeout; timeout > 0 {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return sqlDB.PingContext(ctx)
+		}
+		return sqlDB.PingContext(ctx)
+	}
+
+	var backoff = d.options.Backoff
+	for retry := 0; ; retry++ {
+		err = ping()
+		if err == nil || retry >= d.options.Retries {
+			return err
+		}
+		if d.log != nil {
+			d.log.Warn("ping database", zap.String("db", d.name), zap.Int("retry", retry+1),
+				zap.Duration("backoff", backoff), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %v", ctx.Err(), err)
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > d.options.MaxBackoff {
+			backoff = d.options.MaxBackoff
+		}
+	}
+}
+
+// Implement app.Component interface.
+func (d *DB) Stop(ctx context.Context) error {
+	d.mu.Lock()
+	db := d.db
+	d.db = nil
+	d.mu.Unlock()
+
+	if db == nil {
+		return nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Implement app.HealthChecker interface.
+func (d *DB) Health(ctx context.Context) error {
+	db := d.DB()
+	if db == nil {
+		return ErrNotOpen
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// DB returns the *gorm.DB, it is nil when the component is not started.
+func (d *DB) DB() *gorm.DB {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.db
+}
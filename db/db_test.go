@@ -0,0 +1,268 @@
+package db_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-framework/app"
+	"github.com/go-framework/app/db"
+	_ "github.com/go-framework/app/db/dialects/sqlite"
+	"github.com/go-framework/app/migrate"
+	"github.com/go-framework/configurer"
+	"github.com/go-framework/configurer/templates/sql"
+)
+
+func TestOpen(t *testing.T) {
+	gdb, err := db.Open(sql.Config{DriverName: "sqlite", DSN: "file::memory:"}, nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	sqlDB, _ := gdb.DB()
+	defer sqlDB.Close()
+
+	sqlDB.Exec("SELECT 1")
+	if stats := sqlDB.Stats(); stats.MaxOpenConnections != 1 || stats.Idle != 1 {
+		t.Errorf("Stats() = %+v, want the defaults", stats)
+	}
+
+	// the negative pool settings retain no idle connections without limit.
+	gdb, err = db.Open(sql.Config{DriverName: "sqlite", DSN: "file::memory:", MaxIdleConns: -1, MaxOpenConns: -1}, nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	sqlDB, _ = gdb.DB()
+	defer sqlDB.Close()
+
+	sqlDB.Exec("SELECT 1")
+	if stats := sqlDB.Stats(); stats.MaxOpenConnections != 0 || stats.Idle != 0 {
+		t.Errorf("Stats() = %+v, want no idle connection and no limit", stats)
+	}
+
+	if _, err := db.Open(sql.Config{DriverName: "oracle", DSN: "x"}, nil); !errors.Is(err, db.ErrUnknownDriver) {
+		t.Errorf("Open() error = %v, want %v", err, db.ErrUnknownDriver)
+	}
+}
+
+func TestDB_Lifecycle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var orders = map[string]interface{}{"driverName": "sqlite", "dsn": filepath.Join(dir, "orders.db"), "maxOpenConns": 4}
+	config, err := configurer.New(configurer.MapProvider{"orders": orders})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var d = db.New("orders-db", db.WithKeyOption("orders"))
+	var a = app.New(app.WithConfigOption(config))
+	a.Register(d)
+
+	err = a.Exec(context.Background(), func(ctx context.Context) error {
+		if err := a.Health(ctx); err != nil {
+			return err
+		}
+		sqlDB, _ := d.DB().DB()
+		if got := sqlDB.Stats().MaxOpenConnections; got != 4 {
+			t.Errorf("MaxOpenConnections = %d, want 4", got)
+		}
+		return d.DB().Exec("CREATE TABLE orders (id INTEGER PRIMARY KEY)").Error
+	})
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if d.DB() != nil || !errors.Is(d.Health(context.Background()), db.ErrNotOpen) {
+		t.Errorf("DB() should be closed after stop")
+	}
+
+	// the next run decodes the reloaded configuration.
+	orders["maxOpenConns"] = 8
+	if _, err := config.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	err = a.Exec(context.Background(), func(ctx context.Context) error {
+		sqlDB, _ := d.DB().DB()
+		if got := sqlDB.Stats().MaxOpenConnections; got != 8 {
+			t.Errorf("MaxOpenConnections = %d, want 8", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+}
+
+func TestDB_PingRetry(t *testing.T) {
+	var d = db.New("db",
+		db.WithConfigOption(sql.Config{DriverName: "sqlite", DSN: "/nonexistent/dir/app.db"}),
+		db.WithRetryOption(2, time.Millisecond, 2*time.Millisecond),
+	)
+
+	var start = time.Now()
+	if err := d.Start(context.Background()); err == nil {
+		d.Stop(context.Background())
+		t.Fatalf("Start() want error")
+	}
+	if elapsed := time.Since(start); elapsed < 3*time.Millisecond {
+		t.Errorf("Start() elapsed %v, want the backoff", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d = db.New("db", db.WithConfigOption(sql.Config{DriverName: "sqlite", DSN: "/nonexistent/dir/app.db"}))
+	if err := d.Start(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Start() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestOpen_StatementTimeout(t *testing.T) {
+	gdb, err := db.Open(sql.Config{DriverName: "sqlite", DSN: "file::memory:", StatementTimeout: 50 * time.Millisecond}, nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	sqlDB, _ := gdb.DB()
+	defer sqlDB.Close()
+
+	var start = time.Now()
+	err = gdb.Exec("WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM c) SELECT count(*) FROM c").Error
+	if err == nil || time.Since(start) > 5*time.Second {
+		t.Fatalf("Exec() error = %v after %v, want timeout", err, time.Since(start))
+	}
+	if err := gdb.Exec("CREATE TABLE t (id INTEGER)").Error; err != nil {
+		t.Errorf("Exec() error = %v", err)
+	}
+}
+
+// probe records the max open connections of the db at start.
+type probe struct {
+	db  *db.DB
+	max int
+}
+
+func (p *probe) Name() string                               { return "probe" }
+func (p *probe) DependsOn() []string                        { return []string{p.db.Name()} }
+func (p *probe) Init(ctx context.Context, a *app.App) error { return nil }
+func (p *probe) Stop(ctx context.Context) error             { return nil }
+func (p *probe) Start(ctx context.Context) error {
+	sqlDB, err := p.db.DB().DB()
+	if err == nil {
+		p.max = sqlDB.Stats().MaxOpenConnections
+	}
+	return err
+}
+
+func TestDB_Flags(t *testing.T) {
+	var d = db.New("orders-db", db.WithConfigOption(sql.Config{DriverName: "sqlite", DSN: "file::memory:", MaxOpenConns: 2}))
+	var p = &probe{db: d}
+	var a = app.New()
+	a.Register(d, p)
+
+	var root = app.NewRootCommand(a)
+	root.SetOutput(ioutil.Discard)
+	if err := root.Execute(context.Background(), []string{"--orders-db-max-open-conns", "3", "healthcheck"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if p.max != 3 {
+		t.Errorf("MaxOpenConnections = %d, want 3", p.max)
+	}
+}
+
+func TestDB_FlagsValidate(t *testing.T) {
+	config, err := configurer.New(configurer.MapProvider{
+		"sql": map[string]interface{}{"driverName": "sqlite"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for _, tt := range []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"healthcheck"}, wantErr: "sql.dsn: is required"},
+		{args: []string{"--db-dsn", "file::memory:", "healthcheck"}},
+		{args: []string{"--db-dsn", "file::memory:", "--db-driver-name", "oracle", "healthcheck"}, wantErr: `sql.driverName: "oracle" must be one of`},
+	} {
+		var a = app.New(app.WithConfigOption(config))
+		a.Register(db.New("db"))
+
+		var root = app.NewRootCommand(a)
+		root.SetOutput(ioutil.Discard)
+		err := root.Execute(context.Background(), tt.args)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("Execute(%v) error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDB_Migrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var d = db.New("db",
+		db.WithConfigOption(sql.Config{DriverName: "sqlite", DSN: filepath.Join(dir, "app.db")}),
+		db.WithMigrationsOption(migrate.MapFS{
+			"0001_create_orders.up.sql":   "CREATE TABLE orders (id INTEGER PRIMARY KEY);",
+			"0001_create_orders.down.sql": "DROP TABLE orders;",
+		}),
+	)
+	var a = app.New()
+	a.Register(d)
+
+	var out bytes.Buffer
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"migrate", "--dry-run"}},
+		{args: []string{"migrate", "status"}, want: "pending 0001_create_orders"},
+		{args: []string{"migrate"}},
+		{args: []string{"migrate", "status"}, want: "applied 0001_create_orders"},
+	} {
+		out.Reset()
+		var root = app.NewRootCommand(a)
+		root.SetOutput(&out)
+		if err := root.Execute(context.Background(), tt.args); err != nil {
+			t.Fatalf("Execute(%v) error = %v", tt.args, err)
+		}
+		if !strings.Contains(out.String(), tt.want) {
+			t.Errorf("Execute(%v) output = %q, want %q", tt.args, out.String(), tt.want)
+		}
+	}
+}
+
+func TestDB_MigrateStatementTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var d = db.New("db",
+		db.WithConfigOption(sql.Config{DriverName: "sqlite", DSN: filepath.Join(dir, "app.db"), StatementTimeout: 10 * time.Millisecond}),
+		db.WithMigrationsOption(migrate.MapFS{
+			"0001_slow.up.sql": "CREATE TABLE numbers AS WITH RECURSIVE c(x) AS " +
+				"(SELECT 1 UNION ALL SELECT x+1 FROM c WHERE x < 500000) SELECT x FROM c;",
+		}),
+	)
+	var a = app.New()
+	a.Register(d)
+
+	var root = app.NewRootCommand(a)
+	root.SetOutput(ioutil.Discard)
+	if err := root.Execute(context.Background(), []string{"migrate"}); err != nil {
+		t.Fatalf("Execute() error = %v, want the migration not bounded by the statement timeout", err)
+	}
+}
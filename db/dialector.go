@@ -0,0 +1,29 @@
+package db
+
+import (
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Dialector returns the gorm.Dialector of the data source name.
+type Dialector func(dsn string) gorm.Dialector
+
+var dialectorSet sync.Map // map[string]Dialector
+
+// RegisterDialector registers the dialector of the driver name, like "mysql".
+// The dialects packages register themselves when imported:
+//
+//	import _ "github.com/go-framework/app/db/dialects/mysql"
+func RegisterDialector(name string, dialector Dialector) {
+	dialectorSet.Store(name, dialector)
+}
+
+// GetDialector returns the dialector of the driver name.
+func GetDialector(name string) (Dialector, bool) {
+	value, ok := dialectorSet.Load(name)
+	if !ok {
+		return nil, false
+	}
+	return value.(Dialector), true
+}
@@ -0,0 +1,12 @@
+// Package mysql registers the "mysql" dialector of the db package.
+package mysql
+
+import (
+	"gorm.io/driver/mysql"
+
+	"github.com/go-framework/app/db"
+)
+
+func init() {
+	db.RegisterDialector("mysql", mysql.Open)
+}
@@ -0,0 +1,13 @@
+// Package sqlite registers the "sqlite" dialector of the db package, it
+// requires cgo.
+package sqlite
+
+import (
+	"gorm.io/driver/sqlite"
+
+	"github.com/go-framework/app/db"
+)
+
+func init() {
+	db.RegisterDialector("sqlite", sqlite.Open)
+}
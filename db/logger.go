@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+
+	"github.com/go-framework/configurer"
+	"github.com/go-framework/logger"
+)
+
+// statementKey is the context key of the *gorm.Statement of the traced SQL.
+type statementKey struct{}
+
+// GormLogger implements gorm logger.Interface on the *logger.Logger, so the
+// SQL logs follow its writers and AtomicLevel. The SQL is logged at Debug,
+// the slow SQL at Warn and the failed SQL at Error.
+type GormLogger struct {
+	log     *logger.Logger
+	level   gormlogger.LogLevel
+	options *LoggerOptions
+}
+
+// NewGormLogger returns the GormLogger of the log with options, the level of
+// the gorm LogMode is Info, so the levels are decided by the log.
+func NewGormLogger(log *logger.Logger, opts ...LoggerOption) *GormLogger {
+	options := GetDefaultLoggerOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return &GormLogger{
+		log:     log.WithOptions(zap.WithCaller(false)),
+		level:   gormlogger.Info,
+		options: options,
+	}
+}
+
+// Implement gorm logger.Interface, the level bounds the levels of the log.
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	var c = *l
+	c.level = level
+	return &c
+}
+
+// Implement gorm logger.Interface.
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.log.Info(fmt.Sprintf(msg, data...), zap.String("caller", utils.FileWithLineNum()))
+	}
+}
+
+// Implement gorm logger.Interface.
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.log.Warn(fmt.Sprintf(msg, data...), zap.String("caller", utils.FileWithLineNum()))
+	}
+}
+
+// Implement gorm logger.Interface.
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.log.Error(fmt.Sprintf(msg, data...), zap.String("caller", utils.FileWithLineNum()))
+	}
+}
+
+// Implement gorm logger.Interface, the SQL is not rendered when its level is
+// disabled.
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	var elapsed = time.Since(begin)
+
+	var level, msg = zapcore.DebugLevel, "sql"
+	switch {
+	case err != nil && !(l.options.IgnoreRecordNotFound && errors.Is(err, gorm.ErrRecordNotFound)):
+		if l.level < gormlogger.Error {
+			return
+		}
+		level, msg = zapcore.ErrorLevel, "sql error"
+	case l.options.SlowThreshold > 0 && elapsed > l.options.SlowThreshold:
+		if l.level < gormlogger.Warn {
+			return
+		}
+		level, msg = zapcore.WarnLevel, "slow sql"
+	case l.level < gormlogger.Info:
+		return
+	}
+
+	var ce = l.log.Check(level, msg)
+	if ce == nil {
+		return
+	}
+
+	sql, rows := fc()
+	if l.options.MaskParams {
+		sql = configurer.Mask
+		if stmt, ok := ctx.Value(statementKey{}).(*gorm.Statement); ok {
+			sql = stmt.SQL.String()
+		}
+	}
+
+	var fields = []zap.Field{
+		zap.String("sql", sql),
+		zap.Int64("rows", rows),
+		zap.Duration("elapsed", elapsed),
+		zap.String("caller", utils.FileWithLineNum()),
+	}
+	if level == zapcore.WarnLevel {
+		fields = append(fields, zap.Duration("threshold", l.options.SlowThreshold))
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+	ce.Write(fields...)
+}
+
+// registerStatementContext registers the callbacks which set the statement to
+// its context, so the GormLogger logs the SQL without the parameters.
+func registerStatementContext(db *gorm.DB) error {
+	var before = func(db *gorm.DB) {
+		var ctx = db.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		db.Statement.Context = context.WithValue(ctx, statementKey{}, db.Statement)
+	}
+
+	var callbacks = db.Callback()
+	for _, register := range []func(string, func(*gorm.DB)) error{
+		callbacks.Create().Before("*").Register,
+		callbacks.Query().Before("*").Register,
+		callbacks.Update().Before("*").Register,
+		callbacks.Delete().Before("*").Register,
+		callbacks.Row().Before("*").Register,
+		callbacks.Raw().Before("*").Register,
+	} {
+		if err := register("db:statement_context", before); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package db_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/go-framework/app/db"
+	"github.com/go-framework/configurer/templates/sql"
+)
+
+func newObservedDB(t *testing.T, level zap.AtomicLevel, opts ...db.LoggerOption) (*gorm.DB, *observer.ObservedLogs) {
+	core, logs := observer.New(level)
+	gdb, err := db.Open(sql.Config{DriverName: "sqlite", DSN: "file::memory:"}, &gorm.Config{Logger: db.NewGormLogger(zap.New(core), opts...)})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := gdb.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, password TEXT)").Error; err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	logs.TakeAll()
+	return gdb, logs
+}
+
+func TestGormLogger(t *testing.T) {
+	var level = zap.NewAtomicLevelAt(zap.DebugLevel)
+	gdb, logs := newObservedDB(t, level, db.WithSlowThresholdOption(time.Hour))
+	sqlDB, _ := gdb.DB()
+	defer sqlDB.Close()
+
+	gdb.Exec("INSERT INTO users (password) VALUES (?)", "hunter2")
+	entries := logs.TakeAll()
+	if len(entries) != 1 || entries[0].Level != zapcore.DebugLevel {
+		t.Fatalf("entries = %v, want one debug", entries)
+	}
+	fields := entries[0].ContextMap()
+	if !strings.Contains(fields["sql"].(string), "hunter2") || fields["rows"] != int64(1) ||
+		!strings.Contains(fields["caller"].(string), "logger_test.go") || fields["elapsed"] == nil {
+		t.Errorf("fields = %v", fields)
+	}
+
+	gdb.Exec("SELECT * FROM missing")
+	if entries := logs.TakeAll(); len(entries) != 1 || entries[0].Level != zapcore.ErrorLevel || entries[0].ContextMap()["error"] == nil {
+		t.Errorf("entries = %v, want one error", entries)
+	}
+
+	var user struct{ ID int }
+	gdb.Table("users").Where("id = ?", 42).First(&user)
+	if entries := logs.TakeAll(); len(entries) != 1 || entries[0].Level != zapcore.DebugLevel {
+		t.Errorf("entries = %v, want the record not found at debug", entries)
+	}
+
+	// the runtime level changes apply to the SQL logs.
+	level.SetLevel(zap.InfoLevel)
+	gdb.Exec("INSERT INTO users (password) VALUES (?)", "hunter2")
+	if entries := logs.TakeAll(); len(entries) != 0 {
+		t.Errorf("entries = %v, want none at info", entries)
+	}
+}
+
+func TestGormLogger_Slow(t *testing.T) {
+	var level = zap.NewAtomicLevelAt(zap.InfoLevel)
+	gdb, logs := newObservedDB(t, level, db.WithSlowThresholdOption(time.Nanosecond), db.WithMaskParamsOption(true))
+	sqlDB, _ := gdb.DB()
+	defer sqlDB.Close()
+
+	gdb.Exec("INSERT INTO users (password) VALUES (?)", "hunter2")
+	entries := logs.TakeAll()
+	if len(entries) != 1 || entries[0].Level != zapcore.WarnLevel {
+		t.Fatalf("entries = %v, want one warn", entries)
+	}
+	if got := entries[0].ContextMap()["sql"]; got != "INSERT INTO users (password) VALUES (?)" {
+		t.Errorf("sql = %v, want the masked parameters", got)
+	}
+
+	gdb.Logger = gdb.Logger.LogMode(logger.Silent)
+	gdb.Exec("INSERT INTO users (password) VALUES (?)", "hunter2")
+	if entries := logs.TakeAll(); len(entries) != 0 {
+		t.Errorf("entries = %v, want none in silent mode", entries)
+	}
+}
@@ -0,0 +1,302 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/go-framework/app"
+	"github.com/go-framework/logger"
+)
+
+var (
+	ErrNoPrimary       = errors.New("no primary database")
+	ErrUnknownDatabase = errors.New("unknown database")
+)
+
+// replica of the primary, it is removed from the reads while it is unhealthy.
+type replica struct {
+	db        *DB
+	unhealthy int32
+}
+
+// DBManager is the component of the named databases, like "primary",
+// "replica-1" and "analytics". The reads are routed to the healthy replicas
+// by the Balancer, the writes and the transactions are sent to the primary.
+// The query statements of the primary *gorm.DB, like Find and Count, are
+// routed to the Reader transparently, see Primary to keep them.
+type DBManager struct {
+	name    string
+	options *ManagerOptions
+	log     *logger.Logger
+
+	dbs      map[string]*DB // dbs by name, they are set by Init.
+	names    []string       // names of the dbs in start order, the primary is the first.
+	replicas []*replica
+
+	cancel context.CancelFunc // cancel stops the health checks.
+	wg     sync.WaitGroup
+}
+
+// NewDBManager returns the DBManager component of the name with options.
+func NewDBManager(name string, opts ...ManagerOption) *DBManager {
+	options := GetDefaultManagerOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return &DBManager{name: name, options: options}
+}
+
+// Implement app.Component interface.
+func (m *DBManager) Name() string {
+	return m.name
+}
+
+// Implement app.Dependent interface.
+func (m *DBManager) DependsOn() []string {
+	return []string{app.ConfigName, app.LoggerName}
+}
+
+// Implement app.Component interface, the configs are unmarshalled from the
+// application configuration by the options Key when they are not set.
+func (m *DBManager) Init(ctx context.Context, a *app.App) error {
+	m.log = a.Logger()
+
+	var configs = m.options.Configs
+	if configs == nil {
+		if a.Config() == nil {
+			return app.ErrNoConfig
+		}
+		if err := a.Config().Unmarshal(m.options.Key, &configs); err != nil {
+			return err
+		}
+	}
+	if _, ok := configs[m.options.Primary]; !ok {
+		return fmt.Errorf("%w: %q", ErrNoPrimary, m.options.Primary)
+	}
+
+	var replicas = m.options.Replicas
+	if replicas == nil {
+		for name := range configs {
+			if strings.HasPrefix(name, "replica") {
+				replicas = append(replicas, name)
+			}
+		}
+	}
+	sort.Strings(replicas)
+
+	m.dbs = make(map[string]*DB, len(configs))
+	m.names = []string{m.options.Primary}
+	for name := range configs {
+		if name != m.options.Primary {
+			m.names = append(m.names, name)
+		}
+	}
+	sort.Strings(m.names[1:])
+
+	for _, name := range m.names {
+		// the config is validated by its own key, like databases.replica-1.
+		var opts = append(append([]Option(nil), m.options.Options...),
+			WithConfigOption(configs[name]), WithKeyOption(m.options.Key+"."+name))
+		if contains(replicas, name) {
+			// an unreachable replica is not retried at start, the health
+			// checks open it.
+			opts = append(opts, func(options *Options) { options.Retries = 0 })
+		}
+		var db = New(m.name+"."+name, opts...)
+		if err := db.Init(ctx, a); err != nil {
+			return err
+		}
+		m.dbs[name] = db
+	}
+
+	m.replicas = nil
+	for _, name := range replicas {
+		db, ok := m.dbs[name]
+		if !ok {
+			return fmt.Errorf("%w: replica %q", ErrUnknownDatabase, name)
+		}
+		m.replicas = append(m.replicas, &replica{db: db})
+	}
+
+	return nil
+}
+
+// Implement app.Component interface, the databases are opened in name order
+// after the primary, then the replicas are checked by the options
+// HealthInterval. A replica which fails to open is removed from the reads
+// without ping retries until the health checks open it.
+func (m *DBManager) Start(ctx context.Context) error {
+	for i, name := range m.names {
+		err := m.dbs[name].Start(ctx)
+		if err == nil {
+			continue
+		}
+		if r := m.replica(name); r != nil && ctx.Err() == nil {
+			atomic.StoreInt32(&r.unhealthy, 1)
+			m.log.Warn("replica removed", zap.String("db", r.db.Name()), zap.Error(err))
+			continue
+		}
+		for _, name := range m.names[:i] {
+			m.dbs[name].Stop(ctx)
+		}
+		return err
+	}
+
+	if len(m.replicas) > 0 {
+		if err := m.registerResolver(m.Writer()); err != nil {
+			m.Stop(ctx)
+			return err
+		}
+	}
+
+	if len(m.replicas) > 0 && m.options.HealthInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		m.cancel = cancel
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			m.watch(ctx)
+		}()
+	}
+
+	return nil
+}
+
+// watch checks the health of the replicas until ctx is done.
+func (m *DBManager) watch(ctx context.Context) {
+	var ticker = time.NewTicker(m.options.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkReplicas(ctx)
+		}
+	}
+}
+
+// checkReplicas removes the unhealthy replicas from the reads and restores
+// the recovered ones.
+func (m *DBManager) checkReplicas(ctx context.Context) {
+	for _, r := range m.replicas {
+		ctx, cancel := context.WithTimeout(ctx, m.options.HealthInterval)
+		err := r.db.Health(ctx)
+		if errors.Is(err, ErrNotOpen) {
+			// the replica failed to open at start.
+			err = r.db.Start(ctx)
+		}
+		cancel()
+
+		switch {
+		case err != nil && atomic.CompareAndSwapInt32(&r.unhealthy, 0, 1):
+			m.log.Warn("replica removed", zap.String("db", r.db.Name()), zap.Error(err))
+		case err == nil && atomic.CompareAndSwapInt32(&r.unhealthy, 1, 0):
+			m.log.Info("replica restored", zap.String("db", r.db.Name()))
+		}
+	}
+}
+
+// Implement app.Component interface.
+func (m *DBManager) Stop(ctx context.Context) (errs error) {
+	if m.cancel != nil {
+		m.cancel()
+		m.wg.Wait()
+		m.cancel = nil
+	}
+	for i := len(m.names) - 1; i >= 0; i-- {
+		errs = multierr.Append(errs, m.dbs[m.names[i]].Stop(ctx))
+	}
+	return
+}
+
+// Implement app.HealthChecker interface, the unhealthy replicas are removed
+// from the reads instead of failing the health.
+func (m *DBManager) Health(ctx context.Context) (errs error) {
+	for _, name := range m.names {
+		if m.isReplica(name) {
+			continue
+		}
+		if err := m.dbs[name].Health(ctx); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *DBManager) isReplica(name string) bool {
+	return m.replica(name) != nil
+}
+
+// replica returns the replica of the name, it is nil when the name is not a
+// replica.
+func (m *DBManager) replica(name string) *replica {
+	for _, r := range m.replicas {
+		if r.db == m.dbs[name] {
+			return r
+		}
+	}
+	return nil
+}
+
+// DB returns the *gorm.DB of the name, it is nil when the name is unknown or
+// the component is not started.
+func (m *DBManager) DB(name string) *gorm.DB {
+	db, ok := m.dbs[name]
+	if !ok {
+		return nil
+	}
+	return db.DB()
+}
+
+// Writer returns the primary *gorm.DB, its query statements are routed to
+// the Reader, see Primary.
+func (m *DBManager) Writer() *gorm.DB {
+	return m.DB(m.options.Primary)
+}
+
+// Reader returns the *gorm.DB of a healthy replica picked by the Balancer,
+// it is the primary when no replica is healthy.
+func (m *DBManager) Reader() *gorm.DB {
+	var healthy []*gorm.DB
+	for _, r := range m.replicas {
+		if atomic.LoadInt32(&r.unhealthy) == 0 {
+			if db := r.db.DB(); db != nil {
+				healthy = append(healthy, db)
+			}
+		}
+	}
+	if len(healthy) == 0 {
+		return m.Writer()
+	}
+	return m.options.Balancer(healthy)
+}
+
+// Transaction runs f in a transaction of the primary.
+func (m *DBManager) Transaction(ctx context.Context, f func(tx *gorm.DB) error) error {
+	var db = m.Writer()
+	if db == nil {
+		return ErrNotOpen
+	}
+	return db.WithContext(ctx).Transaction(f)
+}
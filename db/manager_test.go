@@ -0,0 +1,225 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/go-framework/app"
+	"github.com/go-framework/app/db"
+	"github.com/go-framework/configurer"
+	"github.com/go-framework/configurer/templates/sql"
+)
+
+func TestDBManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var databases = make(map[string]interface{})
+	for _, name := range []string{"primary", "replica-1", "replica-2", "analytics"} {
+		databases[name] = map[string]interface{}{"driverName": "sqlite", "dsn": filepath.Join(dir, name+".db")}
+	}
+	config, err := configurer.New(configurer.MapProvider{"databases": databases})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var m = db.NewDBManager("db", db.WithHealthIntervalOption(10*time.Millisecond))
+	var a = app.New(app.WithConfigOption(config))
+	a.Register(m)
+
+	err = a.Exec(context.Background(), func(ctx context.Context) error {
+		var replica1, replica2 = m.DB("replica-1"), m.DB("replica-2")
+		if m.DB("analytics") == nil || m.DB("missing") != nil || m.Writer() != m.DB("primary") {
+			t.Errorf("DB() got unexpected result")
+		}
+		for i, want := range []interface{}{replica1, replica2, replica1} {
+			if m.Reader() != want {
+				t.Errorf("Reader() #%d is not the round-robin replica", i)
+			}
+		}
+
+		err := m.Transaction(ctx, func(tx *gorm.DB) error {
+			return tx.Exec("CREATE TABLE orders (id INTEGER PRIMARY KEY)").Error
+		})
+		if err != nil || !m.Writer().Migrator().HasTable("orders") || replica1.Migrator().HasTable("orders") {
+			t.Errorf("Transaction() error = %v, want the primary", err)
+		}
+
+		// the closed replica is removed from the reads.
+		sqlDB, _ := replica1.DB()
+		sqlDB.Close()
+		time.Sleep(50 * time.Millisecond)
+		for i := 0; i < 3; i++ {
+			if m.Reader() != replica2 {
+				t.Errorf("Reader() is the unhealthy replica")
+			}
+		}
+		return a.Health(ctx)
+	})
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if m.Writer() != nil {
+		t.Errorf("Writer() should be closed after stop")
+	}
+}
+
+func TestDBManager_UnreachableReplica(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var m = db.NewDBManager("db",
+		db.WithConfigsOption(map[string]sql.Config{
+			"primary": {DriverName: "sqlite", DSN: filepath.Join(dir, "primary.db")},
+			"replica": {DriverName: "sqlite", DSN: filepath.Join(dir, "replica", "replica.db")},
+		}),
+		db.WithHealthIntervalOption(10*time.Millisecond),
+	)
+	var a = app.New()
+	a.Register(m)
+
+	var begin = time.Now()
+	err = a.Exec(context.Background(), func(ctx context.Context) error {
+		// the unreachable replica is not retried at start.
+		if elapsed := time.Since(begin); elapsed > time.Second {
+			t.Errorf("Start() elapsed = %v, want without the ping retries", elapsed)
+		}
+		if m.DB("replica") != nil || m.Reader() != m.Writer() {
+			t.Errorf("Reader() should be the primary while the replica is unreachable")
+		}
+
+		// the health checks open the reachable replica.
+		os.Mkdir(filepath.Join(dir, "replica"), 0755)
+		for i := 0; i < 100 && m.Reader() == m.Writer(); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if replica := m.DB("replica"); replica == nil || m.Reader() != replica {
+			t.Errorf("Reader() should be the restored replica")
+		}
+		return a.Health(ctx)
+	})
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+}
+
+func TestDBManager_LeastConnections(t *testing.T) {
+	var configs = map[string]sql.Config{
+		"primary": {DriverName: "sqlite", DSN: "file:primary?mode=memory", MaxOpenConns: 2},
+		"replica": {DriverName: "sqlite", DSN: "file:replica?mode=memory", MaxOpenConns: 2},
+		"standby": {DriverName: "sqlite", DSN: "file:standby?mode=memory", MaxOpenConns: 2},
+	}
+	var m = db.NewDBManager("db",
+		db.WithConfigsOption(configs),
+		db.WithReplicasOption("replica", "standby"),
+		db.WithBalancerOption(db.LeastConnections()),
+	)
+	var a = app.New()
+	a.Register(m)
+
+	err := a.Exec(context.Background(), func(ctx context.Context) error {
+		sqlDB, _ := m.DB("replica").DB()
+		conn, err := sqlDB.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if m.Reader() != m.DB("standby") {
+			t.Errorf("Reader() is not the replica with the least connections")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+
+	m = db.NewDBManager("db", db.WithConfigsOption(map[string]sql.Config{"replica": configs["replica"]}))
+	a = app.New()
+	a.Register(m)
+	if err := a.Exec(context.Background(), func(ctx context.Context) error { return nil }); !errors.Is(err, db.ErrNoPrimary) {
+		t.Errorf("Exec() error = %v, want %v", err, db.ErrNoPrimary)
+	}
+}
+
+func TestDBManager_Resolver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var configs = make(map[string]sql.Config)
+	for _, name := range []string{"primary", "replica"} {
+		configs[name] = sql.Config{DriverName: "sqlite", DSN: filepath.Join(dir, name+".db")}
+	}
+	var m = db.NewDBManager("db", db.WithConfigsOption(configs))
+	var a = app.New()
+	a.Register(m)
+
+	type Order struct {
+		ID   int
+		Name string
+	}
+	var names = func(tx *gorm.DB) (names []string) {
+		tx.Model(&Order{}).Order("id").Pluck("name", &names)
+		return
+	}
+
+	err = a.Exec(context.Background(), func(ctx context.Context) error {
+		for _, name := range []string{"primary", "replica"} {
+			if err := m.DB(name).AutoMigrate(&Order{}); err != nil {
+				return err
+			}
+			m.DB(name).Exec("INSERT INTO orders (name) VALUES (?)", name)
+		}
+
+		// the writes of the primary session are not routed.
+		if err := m.Writer().Create(&Order{Name: "created"}).Error; err != nil {
+			return err
+		}
+		if got := names(m.Writer()); len(got) != 1 || got[0] != "replica" {
+			t.Errorf("Pluck() = %v, want the replica", got)
+		}
+		if got := names(db.Primary(m.Writer())); len(got) != 2 || got[1] != "created" {
+			t.Errorf("Pluck() = %v, want the primary", got)
+		}
+		return m.Transaction(ctx, func(tx *gorm.DB) error {
+			if got := names(tx); len(got) != 2 {
+				t.Errorf("Pluck() = %v, want the primary in the transaction", got)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+}
+
+func TestDBManager_ValidateKey(t *testing.T) {
+	var m = db.NewDBManager("db", db.WithConfigsOption(map[string]sql.Config{
+		"primary":   {DriverName: "sqlite", DSN: "file::memory:"},
+		"replica-1": {DriverName: "sqlite"},
+	}))
+	var a = app.New()
+	a.Register(m)
+
+	err := a.Exec(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "databases.replica-1.dsn") {
+		t.Errorf("Exec() error = %v, want the error of databases.replica-1.dsn", err)
+	}
+}
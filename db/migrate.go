@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.uber.org/zap"
+
+	"github.com/go-framework/app"
+	"github.com/go-framework/app/migrate"
+)
+
+// migrator returns the migrate.Migrator of the options Migrations, the dry run
+// is set by the context.
+func (d *DB) migrator(ctx context.Context) (*migrate.Migrator, error) {
+	db := d.DB()
+	if db == nil {
+		return nil, ErrNotOpen
+	}
+	var opts = append([]migrate.Option{migrate.WithEventOption(d.event)}, d.options.MigrationOptions...)
+	if app.GetDryRunFromContext(ctx) {
+		opts = append(opts, migrate.WithDryRunOption(true))
+	}
+	return migrate.New(db, d.options.Migrations, opts...), nil
+}
+
+// Implement app.Migrator interface, the pending migrations of the options
+// Migrations are applied.
+func (d *DB) Migrate(ctx context.Context) error {
+	if d.options.Migrations == nil {
+		return nil
+	}
+	m, err := d.migrator(ctx)
+	if err != nil {
+		return err
+	}
+
+	steps, err := m.Up(ctx)
+	for _, step := range steps {
+		if step.DryRun {
+			d.log.Info("migration planned", zap.String("db", d.name), zap.Stringer("migration", step.Migration),
+				zap.String("sql", step.SQL))
+			continue
+		}
+		d.log.Info("migration applied", zap.String("db", d.name), zap.Stringer("migration", step.Migration),
+			zap.Duration("elapsed", step.Elapsed))
+	}
+	return err
+}
+
+// Implement app.MigrationReporter interface.
+func (d *DB) MigrationStatus(ctx context.Context, w io.Writer) error {
+	if d.options.Migrations == nil {
+		return nil
+	}
+	m, err := d.migrator(ctx)
+	if err != nil {
+		return err
+	}
+
+	list, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, status := range list {
+		if _, err := fmt.Fprintf(w, "  %s\n", status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Implement app.Migrator interface, the migrations of the primary are applied
+// and the replicas follow it.
+func (m *DBManager) Migrate(ctx context.Context) error {
+	db, ok := m.dbs[m.options.Primary]
+	if !ok {
+		return ErrNotOpen
+	}
+	return db.Migrate(ctx)
+}
+
+// Implement app.MigrationReporter interface, the status of the primary is
+// reported.
+func (m *DBManager) MigrationStatus(ctx context.Context, w io.Writer) error {
+	db, ok := m.dbs[m.options.Primary]
+	if !ok {
+		return ErrNotOpen
+	}
+	return db.MigrationStatus(ctx, w)
+}
@@ -0,0 +1,200 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/go-framework/app/migrate"
+	"github.com/go-framework/configurer/templates/sql"
+)
+
+// DB option func.
+type Option func(options *Options)
+
+// DB options.
+type Options struct {
+	Key        string        // Configuration key of the sql.Config, default is "sql".
+	Config     *sql.Config   // Database config, it is unmarshalled from the application configuration by Key when nil.
+	Gorm       *gorm.Config  // Gorm config, default is the zero gorm.Config.
+	Retries    int           // Ping retries at start, default is 5.
+	Backoff    time.Duration // Initial ping retry backoff, it is doubled after each retry, default is 100 milliseconds.
+	MaxBackoff time.Duration // Maximum ping retry backoff, default is 5 seconds.
+
+	Migrations       migrate.FileSystem // Migrations of the migrate command, nil disables them.
+	MigrationOptions []migrate.Option   // Options of the migrate.Migrator.
+
+	LoggerOptions []LoggerOption // Options of the GormLogger of the application logger, it is used when the Gorm Logger is nil.
+}
+
+// Get default Options value.
+func GetDefaultOptions() *Options {
+	return &Options{
+		Key:        "sql",
+		Gorm:       &gorm.Config{},
+		Retries:    5,
+		Backoff:    100 * time.Millisecond,
+		MaxBackoff: 5 * time.Second,
+	}
+}
+
+// WithKeyOption set the configuration key of the sql.Config.
+func WithKeyOption(key string) Option {
+	return func(options *Options) {
+		options.Key = key
+	}
+}
+
+// WithConfigOption set the database config instead of the application
+// configuration.
+func WithConfigOption(config sql.Config) Option {
+	return func(options *Options) {
+		options.Config = &config
+	}
+}
+
+// WithGormOption set the gorm config.
+func WithGormOption(config *gorm.Config) Option {
+	return func(options *Options) {
+		options.Gorm = config
+	}
+}
+
+// WithRetryOption set the ping retries and the backoff bounds.
+func WithRetryOption(retries int, backoff, maxBackoff time.Duration) Option {
+	return func(options *Options) {
+		options.Retries = retries
+		options.Backoff = backoff
+		options.MaxBackoff = maxBackoff
+	}
+}
+
+// WithMigrationsOption set the migrations of the migrate command and the
+// options of the migrate.Migrator.
+func WithMigrationsOption(fs migrate.FileSystem, opts ...migrate.Option) Option {
+	return func(options *Options) {
+		options.Migrations = fs
+		options.MigrationOptions = opts
+	}
+}
+
+// WithSQLLoggerOption set the options of the GormLogger of the SQL logs.
+func WithSQLLoggerOption(opts ...LoggerOption) Option {
+	return func(options *Options) {
+		options.LoggerOptions = opts
+	}
+}
+
+// GormLogger option func.
+type LoggerOption func(options *LoggerOptions)
+
+// GormLogger options.
+type LoggerOptions struct {
+	SlowThreshold        time.Duration // SQL slower than it is logged at Warn, zero disables it, default is 200 milliseconds.
+	MaskParams           bool          // MaskParams logs the SQL with the placeholders instead of the parameters.
+	IgnoreRecordNotFound bool          // IgnoreRecordNotFound does not log gorm.ErrRecordNotFound at Error, default is true.
+}
+
+// Get default LoggerOptions value.
+func GetDefaultLoggerOptions() *LoggerOptions {
+	return &LoggerOptions{
+		SlowThreshold:        200 * time.Millisecond,
+		IgnoreRecordNotFound: true,
+	}
+}
+
+// WithSlowThresholdOption set the slow SQL threshold.
+func WithSlowThresholdOption(threshold time.Duration) LoggerOption {
+	return func(options *LoggerOptions) {
+		options.SlowThreshold = threshold
+	}
+}
+
+// WithMaskParamsOption set whether the parameters of the SQL are masked.
+func WithMaskParamsOption(mask bool) LoggerOption {
+	return func(options *LoggerOptions) {
+		options.MaskParams = mask
+	}
+}
+
+// WithIgnoreRecordNotFoundOption set whether gorm.ErrRecordNotFound is
+// ignored.
+func WithIgnoreRecordNotFoundOption(ignore bool) LoggerOption {
+	return func(options *LoggerOptions) {
+		options.IgnoreRecordNotFound = ignore
+	}
+}
+
+// DBManager option func.
+type ManagerOption func(options *ManagerOptions)
+
+// DBManager options.
+type ManagerOptions struct {
+	Key            string                // Configuration key of the map[string]sql.Config, default is "databases".
+	Configs        map[string]sql.Config // Database configs by name, they are unmarshalled from the application configuration by Key when nil.
+	Primary        string                // Name of the primary database, default is "primary".
+	Replicas       []string              // Names of the replicas of the primary, default is the names with the "replica" prefix.
+	Balancer       Balancer              // Balancer of the reads among the healthy replicas, default is RoundRobin.
+	HealthInterval time.Duration         // Interval of the replica health checks, default is 5 seconds.
+	Options        []Option              // Options of each database.
+}
+
+// Get default ManagerOptions value.
+func GetDefaultManagerOptions() *ManagerOptions {
+	return &ManagerOptions{
+		Key:            "databases",
+		Primary:        "primary",
+		Balancer:       RoundRobin(),
+		HealthInterval: 5 * time.Second,
+	}
+}
+
+// WithDatabasesKeyOption set the configuration key of the database configs.
+func WithDatabasesKeyOption(key string) ManagerOption {
+	return func(options *ManagerOptions) {
+		options.Key = key
+	}
+}
+
+// WithConfigsOption set the database configs instead of the application
+// configuration.
+func WithConfigsOption(configs map[string]sql.Config) ManagerOption {
+	return func(options *ManagerOptions) {
+		options.Configs = configs
+	}
+}
+
+// WithPrimaryOption set the name of the primary database.
+func WithPrimaryOption(name string) ManagerOption {
+	return func(options *ManagerOptions) {
+		options.Primary = name
+	}
+}
+
+// WithReplicasOption set the names of the replicas.
+func WithReplicasOption(names ...string) ManagerOption {
+	return func(options *ManagerOptions) {
+		options.Replicas = names
+	}
+}
+
+// WithBalancerOption set the balancer of the reads, like LeastConnections.
+func WithBalancerOption(balancer Balancer) ManagerOption {
+	return func(options *ManagerOptions) {
+		options.Balancer = balancer
+	}
+}
+
+// WithHealthIntervalOption set the interval of the replica health checks.
+func WithHealthIntervalOption(interval time.Duration) ManagerOption {
+	return func(options *ManagerOptions) {
+		options.HealthInterval = interval
+	}
+}
+
+// WithDBOption set the options of each database.
+func WithDBOption(opts ...Option) ManagerOption {
+	return func(options *ManagerOptions) {
+		options.Options = opts
+	}
+}
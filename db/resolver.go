@@ -0,0 +1,60 @@
+package db
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/go-framework/app/migrate"
+)
+
+const (
+	// primaryKey is the statement setting which keeps a read on the primary.
+	primaryKey = "db:primary"
+	// connPoolKey is the statement setting of the ConnPool replaced by a replica.
+	connPoolKey = "db:primary_conn_pool"
+)
+
+// Primary returns the session of the db whose reads are not routed to the
+// replicas, like the reads which must see the writes just committed.
+func Primary(db *gorm.DB) *gorm.DB {
+	return db.Set(primaryKey, true)
+}
+
+// registerResolver registers the callbacks of the primary which route the
+// query statements, like Find, First, Count and Pluck, to the Reader. The
+// statements of a transaction, a locking clause like FOR UPDATE, a Primary
+// session or the migrations are not routed, neither are the raw and row
+// statements.
+func (m *DBManager) registerResolver(db *gorm.DB) error {
+	var before = func(db *gorm.DB) {
+		if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+			return
+		}
+		if _, ok := db.Statement.Clauses[clause.Locking{}.Name()]; ok {
+			return
+		}
+		if _, ok := db.Get(primaryKey); ok {
+			return
+		}
+		if ctx := db.Statement.Context; ctx != nil && migrate.IsMigrating(ctx) {
+			return
+		}
+		if reader := m.Reader(); reader != nil && reader.ConnPool != db.Statement.ConnPool {
+			db.Statement.Settings.Store(connPoolKey, db.Statement.ConnPool)
+			db.Statement.ConnPool = reader.ConnPool
+		}
+	}
+	var after = func(db *gorm.DB) {
+		// the statement may be reused by a write of the same session.
+		if pool, ok := db.Statement.Settings.Load(connPoolKey); ok {
+			db.Statement.Settings.Delete(connPoolKey)
+			db.Statement.ConnPool = pool.(gorm.ConnPool)
+		}
+	}
+
+	var callbacks = db.Callback().Query()
+	if err := callbacks.Before("gorm:query").Register("db:resolver", before); err != nil {
+		return err
+	}
+	return callbacks.After("gorm:query").Register("db:resolver_restore", after)
+}
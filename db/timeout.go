@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/go-framework/app/migrate"
+)
+
+// cancelKey is the statement setting of the timeout cancel func.
+const cancelKey = "db:statement_timeout_cancel"
+
+// registerStatementTimeout registers the callbacks which bound the create,
+// query, update, delete and raw statements by the timeout when their context
+// has no deadline. The row statements are not bounded, because their rows are
+// read after the callbacks, and the migrations are not bounded, see
+// migrate.IsMigrating.
+func registerStatementTimeout(db *gorm.DB, timeout time.Duration) error {
+	var before = func(db *gorm.DB) {
+		var ctx = db.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if _, ok := ctx.Deadline(); ok || migrate.IsMigrating(ctx) {
+			return
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		db.Statement.Context = ctx
+		db.Statement.Settings.Store(cancelKey, cancel)
+	}
+	var after = func(db *gorm.DB) {
+		if cancel, ok := db.Statement.Settings.Load(cancelKey); ok {
+			db.Statement.Settings.Delete(cancelKey)
+			cancel.(context.CancelFunc)()
+		}
+	}
+
+	var callbacks = db.Callback()
+	for _, register := range []func(string, func(*gorm.DB)) error{
+		callbacks.Create().Before("gorm:create").Register,
+		callbacks.Query().Before("gorm:query").Register,
+		callbacks.Update().Before("gorm:update").Register,
+		callbacks.Delete().Before("gorm:delete").Register,
+		callbacks.Raw().Before("gorm:raw").Register,
+	} {
+		if err := register("db:statement_timeout", before); err != nil {
+			return err
+		}
+	}
+	for _, register := range []func(string, func(*gorm.DB)) error{
+		callbacks.Create().After("gorm:create").Register,
+		callbacks.Query().After("gorm:query").Register,
+		callbacks.Update().After("gorm:update").Register,
+		callbacks.Delete().After("gorm:delete").Register,
+		callbacks.Raw().After("gorm:raw").Register,
+	} {
+		if err := register("db:statement_timeout_cancel", after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
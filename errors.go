@@ -0,0 +1,22 @@
+package app
+
+import (
+	"errors"
+)
+
+var (
+	ErrAlreadyRunning     = errors.New("app already running")
+	ErrNotRunning         = errors.New("app not running")
+	ErrNilComponent       = errors.New("nil component")
+	ErrDuplicateComponent = errors.New("duplicate component")
+	ErrUnknownDependency  = errors.New("unknown dependency")
+	ErrDependencyCycle    = errors.New("dependency cycle")
+	ErrInvalidConstructor = errors.New("invalid constructor")
+	ErrDuplicateProvider  = errors.New("duplicate provider")
+	ErrMissingProvider    = errors.New("missing provider")
+	ErrProviderCycle      = errors.New("provider cycle")
+	ErrUnknownCommand     = errors.New("unknown command")
+	ErrNoConfig           = errors.New("no configuration")
+	ErrNoConfigLoader     = errors.New("no configuration loader")
+	ErrUnknownKey         = errors.New("unknown configuration key")
+)
@@ -3,8 +3,21 @@ module github.com/go-framework/app
 go 1.14
 
 require (
-	github.com/spf13/pflag v1.0.5 // indirect
-	go.uber.org/multierr v1.6.0 // indirect
-	go.uber.org/zap v1.16.0 // indirect
-	gorm.io/gorm v1.20.9 // indirect
+	github.com/go-framework/configurer v0.0.0-00010101000000-000000000000
+	github.com/go-framework/event v0.0.0-00010101000000-000000000000
+	github.com/go-framework/logger v0.0.0-00010101000000-000000000000
+	github.com/mattn/go-sqlite3 v1.14.6 // indirect
+	github.com/natefinch/lumberjack v2.0.0+incompatible
+	github.com/spf13/pflag v1.0.5
+	go.uber.org/multierr v1.6.0
+	go.uber.org/zap v1.16.0
+	gorm.io/driver/mysql v1.0.1
+	gorm.io/driver/sqlite v1.1.4
+	gorm.io/gorm v1.20.9
+)
+
+replace (
+	github.com/go-framework/configurer => ./vendor/github.com/go-framework/configurer
+	github.com/go-framework/event => ./vendor/github.com/go-framework/event
+	github.com/go-framework/logger => ./vendor/github.com/go-framework/logger
 )
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// graph is the components DAG built from their dependencies.
+type graph struct {
+	components []Component         // components in registration order.
+	index      map[string]int      // component name to registration index.
+	edges      map[string][]string // component name to its dependencies.
+}
+
+// newGraph builds the graph, dependencies must be registered components or built-in names.
+func newGraph(components []Component) (*graph, error) {
+	var g = &graph{
+		components: components,
+		index:      make(map[string]int, len(components)),
+		edges:      make(map[string][]string, len(components)),
+	}
+
+	for i, component := range components {
+		g.index[component.Name()] = i
+	}
+
+	for _, component := range components {
+		for _, dep := range dependencies(component) {
+			switch dep {
+			case LoggerName, ConfigName, EventName:
+				continue
+			}
+			if _, ok := g.index[dep]; !ok {
+				return nil, fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, component.Name(), dep)
+			}
+			g.edges[component.Name()] = append(g.edges[component.Name()], dep)
+		}
+	}
+
+	return g, nil
+}
+
+// levels returns the components grouped by start level, all dependencies of a
+// component are in former levels so components of the same level can start in
+// parallel. Components of a level keep the registration order.
+func (g *graph) levels() ([][]Component, error) {
+	var (
+		levels  [][]Component
+		visited = make(map[string]bool, len(g.components))
+	)
+
+	for len(visited) < len(g.components) {
+		var level []Component
+		for _, component := range g.components {
+			if visited[component.Name()] {
+				continue
+			}
+			var ready = true
+			for _, dep := range g.edges[component.Name()] {
+				if !visited[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				level = append(level, component)
+			}
+		}
+
+		if len(level) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(g.cycle(visited), " -> "))
+		}
+
+		for _, component := range level {
+			visited[component.Name()] = true
+		}
+		levels = append(levels, level)
+	}
+
+	return levels, nil
+}
+
+// cycle returns a dependency cycle path among the unvisited components, the
+// first name is repeated at the end.
+func (g *graph) cycle(visited map[string]bool) []string {
+	const (
+		white = iota // not seen.
+		gray         // in the current path.
+		black        // done without cycle.
+	)
+
+	var (
+		color = make(map[string]int, len(g.components))
+		path  []string
+		found []string
+		walk  func(name string) bool
+	)
+
+	walk = func(name string) bool {
+		color[name] = gray
+		path = append(path, name)
+		for _, dep := range g.edges[name] {
+			if visited[dep] {
+				continue
+			}
+			switch color[dep] {
+			case gray:
+				for i := range path {
+					if path[i] == dep {
+						found = append(append(found, path[i:]...), dep)
+						return true
+					}
+				}
+			case white:
+				if walk(dep) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		color[name] = black
+		return false
+	}
+
+	for _, component := range g.components {
+		if !visited[component.Name()] && color[component.Name()] == white && walk(component.Name()) {
+			break
+		}
+	}
+
+	return found
+}
+
+// flatten returns the components of levels in start order.
+func flatten(levels [][]Component) []Component {
+	var list []Component
+	for _, level := range levels {
+		list = append(list, level...)
+	}
+	return list
+}
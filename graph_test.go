@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func names(levels [][]Component) [][]string {
+	var list [][]string
+	for _, level := range levels {
+		var items []string
+		for _, component := range level {
+			items = append(items, component.Name())
+		}
+		list = append(list, items)
+	}
+	return list
+}
+
+func Test_graph_levels(t *testing.T) {
+	tests := []struct {
+		name       string
+		components []Component
+		want       [][]string
+		wantErr    error
+		wantMsg    string
+	}{
+		{
+			name: "independent",
+			components: []Component{
+				&testComponent{name: "a"},
+				&testComponent{name: "b"},
+			},
+			want: [][]string{{"a", "b"}},
+		},
+		{
+			name: "dependencies",
+			components: []Component{
+				&testComponent{name: "db", deps: []string{ConfigName, LoggerName, "migrate"}},
+				&testComponent{name: "http", deps: []string{"db", "cache"}},
+				&testComponent{name: "cache", deps: []string{LoggerName}},
+				&testComponent{name: "migrate"},
+				&testComponent{name: "subscriber", deps: []string{EventName}},
+			},
+			want: [][]string{{"cache", "migrate", "subscriber"}, {"db"}, {"http"}},
+		},
+		{
+			name: "unknown",
+			components: []Component{
+				&testComponent{name: "a", deps: []string{"b"}},
+			},
+			wantErr: ErrUnknownDependency,
+			wantMsg: "unknown dependency: a depends on b",
+		},
+		{
+			name: "cycle",
+			components: []Component{
+				&testComponent{name: "root"},
+				&testComponent{name: "a", deps: []string{"root", "c"}},
+				&testComponent{name: "b", deps: []string{"a"}},
+				&testComponent{name: "c", deps: []string{"b"}},
+			},
+			wantErr: ErrDependencyCycle,
+			wantMsg: "dependency cycle: a -> c -> b -> a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := newGraph(tt.components)
+			if err == nil {
+				var levels [][]Component
+				levels, err = g.levels()
+				if err == nil && !reflect.DeepEqual(names(levels), tt.want) {
+					t.Errorf("levels() = %v, want %v", names(levels), tt.want)
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("levels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && err.Error() != tt.wantMsg {
+				t.Errorf("levels() error = %q, want %q", err.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+// barrierComponent starts only when all components of the barrier are starting.
+type barrierComponent struct {
+	testComponent
+	barrier chan struct{}
+	size    int
+}
+
+func (c *barrierComponent) Start(ctx context.Context) error {
+	c.barrier <- struct{}{}
+	for {
+		if len(c.barrier) == c.size {
+			return nil
+		}
+		select {
+		case <-time.After(time.Second):
+			return errors.New("not started in parallel")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestApp_ParallelStart(t *testing.T) {
+	var r = &recorder{}
+	var barrier = make(chan struct{}, 2)
+	var a = New()
+
+	a.Register(
+		&barrierComponent{testComponent: testComponent{name: "a", recorder: r}, barrier: barrier, size: 2},
+		&barrierComponent{testComponent: testComponent{name: "b", recorder: r}, barrier: barrier, size: 2},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := a.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
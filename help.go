@@ -0,0 +1,82 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Help returns the generated help of the command.
+func (c *Command) Help() string {
+	var b strings.Builder
+
+	if desc := strings.TrimSpace(c.Long); desc != "" {
+		b.WriteString(desc + "\n\n")
+	} else if c.Short != "" {
+		b.WriteString(c.Short + "\n\n")
+	}
+
+	b.WriteString("Usage:\n")
+	if c.Run != nil {
+		var usage = c.Usage
+		if usage == "" {
+			usage = "[flags]"
+		}
+		fmt.Fprintf(&b, "  %s %s\n", c.Path(), usage)
+	}
+	var commands = c.visibleCommands()
+	if len(commands) > 0 {
+		fmt.Fprintf(&b, "  %s [command]\n", c.Path())
+	}
+
+	if len(c.Aliases) > 0 {
+		fmt.Fprintf(&b, "\nAliases:\n  %s\n", strings.Join(append([]string{c.Name}, c.Aliases...), ", "))
+	}
+
+	if len(commands) > 0 {
+		var width = 0
+		for _, command := range commands {
+			if len(command.Name) > width {
+				width = len(command.Name)
+			}
+		}
+		b.WriteString("\nAvailable Commands:\n")
+		for _, command := range commands {
+			fmt.Fprintf(&b, "  %-*s   %s\n", width, command.Name, command.Short)
+		}
+	}
+
+	// the --help flag is shown as it is added by Execute.
+	var local, all = c.LocalFlags(), c.LocalFlags()
+	all.AddFlagSet(c.InheritedFlags())
+	if addHelpFlag(all, c.Name) != nil {
+		local.AddFlag(all.Lookup("help"))
+	}
+	b.WriteString("\nFlags:\n" + local.FlagUsages())
+
+	if inherited := c.InheritedFlags(); inherited.HasFlags() {
+		b.WriteString("\nGlobal Flags:\n" + inherited.FlagUsages())
+	}
+
+	if len(commands) > 0 {
+		fmt.Fprintf(&b, "\nUse \"%s [command] --help\" for more information about a command.\n", c.Path())
+	}
+
+	return b.String()
+}
+
+// PrintHelp prints the generated help to the command output.
+func (c *Command) PrintHelp() error {
+	_, err := fmt.Fprint(c.Out(), c.Help())
+	return err
+}
+
+// visibleCommands returns the sub commands which are not hidden.
+func (c *Command) visibleCommands() []*Command {
+	var list []*Command
+	for _, command := range c.commands {
+		if !command.Hidden {
+			list = append(list, command)
+		}
+	}
+	return list
+}
@@ -0,0 +1,63 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FileSystem is the source of the migration files, it is implemented by Dir
+// and MapFS, and can be implemented by the generated or embedded assets.
+type FileSystem interface {
+	// List returns the names of the files.
+	List() ([]string, error)
+	// ReadFile returns the content of the named file.
+	ReadFile(name string) ([]byte, error)
+}
+
+// Dir is the FileSystem of the files in the directory, sub directories are
+// not listed.
+type Dir string
+
+// Implement FileSystem interface.
+func (d Dir) List() ([]string, error) {
+	infos, err := ioutil.ReadDir(string(d))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, info := range infos {
+		if info.Mode().IsRegular() {
+			names = append(names, info.Name())
+		}
+	}
+	return names, nil
+}
+
+// Implement FileSystem interface.
+func (d Dir) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(string(d), name))
+}
+
+// MapFS is the FileSystem of the file contents by name.
+type MapFS map[string]string
+
+// Implement FileSystem interface.
+func (m MapFS) List() ([]string, error) {
+	var names = make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Implement FileSystem interface.
+func (m MapFS) ReadFile(name string) ([]byte, error) {
+	content, ok := m[name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return []byte(content), nil
+}
@@ -0,0 +1,124 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"time"
+)
+
+var ErrLocked = errors.New("migration locked")
+
+// lockRetryInterval is the interval of trying to take a held lock.
+const lockRetryInterval = 100 * time.Millisecond
+
+// lock takes the advisory lock of the migrations until the returned unlock
+// is called, so the concurrent deploys run the migrations one by one. The
+// lock of mysql and postgres is held by a session, the other databases use a
+// row of the <table>_lock table, which should be deleted by hand if a holder
+// crashed.
+func (m *Migrator) lock(ctx context.Context) (unlock func() error, err error) {
+	ctx, cancel := context.WithTimeout(ctx, m.options.LockTimeout)
+	defer cancel()
+
+	var name = m.options.Table + "_lock"
+	switch m.db.Dialector.Name() {
+	case "mysql":
+		return m.sessionLock(ctx, "SELECT GET_LOCK(?, 0)", "SELECT RELEASE_LOCK(?)", name)
+	case "postgres":
+		var key = int64(crc32.ChecksumIEEE([]byte(name)))
+		return m.sessionLock(ctx, "SELECT pg_try_advisory_lock($1)", "SELECT pg_advisory_unlock($1)", key)
+	default:
+		return m.tableLock(ctx, name)
+	}
+}
+
+// sessionLock takes the lock by the query which returns true when the lock is
+// taken, the lock is held by a dedicated connection.
+func (m *Migrator) sessionLock(ctx context.Context, lock, unlock string, key interface{}) (func() error, error) {
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = retryLock(ctx, func() (bool, error) {
+		var locked sql.NullBool
+		if err := conn.QueryRowContext(ctx, lock, key).Scan(&locked); err != nil {
+			return false, err
+		}
+		return locked.Valid && locked.Bool, nil
+	})
+	if err != nil {
+		conn.Close()
+		// the lock query interrupted by the lock timeout waited for the holder.
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !errors.Is(err, ErrLocked) {
+			err = fmt.Errorf("%w: %v", ErrLocked, ctx.Err())
+		}
+		return nil, err
+	}
+
+	return func() error {
+		defer conn.Close()
+		_, err := conn.ExecContext(context.Background(), unlock, key)
+		return err
+	}, nil
+}
+
+// tableLock takes the lock by inserting the row of the lock table, the failed
+// insert is retried while the row exists.
+func (m *Migrator) tableLock(ctx context.Context, table string) (func() error, error) {
+	var db = m.db.WithContext(ctx)
+	err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id INTEGER NOT NULL PRIMARY KEY, locked_at TIMESTAMP NOT NULL)", table)).Error
+	if err != nil {
+		return nil, fmt.Errorf("create lock table %s: %w", table, err)
+	}
+
+	var lockedAt time.Time
+	err = retryLock(ctx, func() (bool, error) {
+		err := db.Exec(fmt.Sprintf("INSERT INTO %s (id, locked_at) VALUES (1, ?)", table), time.Now().UTC()).Error
+		if err == nil {
+			return true, nil
+		}
+		var rows []time.Time
+		if e := db.Table(table).Where("id = 1").Pluck("locked_at", &rows).Error; e != nil || len(rows) == 0 {
+			return false, fmt.Errorf("insert lock row of %s: %w", table, err)
+		}
+		lockedAt = rows[0]
+		return false, nil
+	})
+	if errors.Is(err, ErrLocked) {
+		return nil, fmt.Errorf("%w since %s by the row of %s, delete it if the holder crashed",
+			err, lockedAt.Format(time.RFC3339), table)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		if err := m.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = 1", table)).Error; err != nil {
+			return fmt.Errorf("delete lock row of %s: %w", table, err)
+		}
+		return nil
+	}, nil
+}
+
+// retryLock tries to take the lock until it is taken or ctx is done.
+func retryLock(ctx context.Context, try func() (bool, error)) error {
+	for {
+		locked, err := try()
+		if err != nil || locked {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %v", ErrLocked, ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// Package migrate applies the versioned SQL files like 0001_create_users.up.sql
+// and 0001_create_users.down.sql to a database. The applied versions are
+// tracked in a table and the concurrent runs are serialized by a lock.
+//
+// The files of a version are executed in a transaction, the driver should
+// support multiple statements in an Exec, like the mysql DSN parameter
+// multiStatements=true.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/multierr"
+	"gorm.io/gorm"
+
+	"github.com/go-framework/event/inapp"
+)
+
+// AppliedEvent is published on the options Event with the Step after each
+// step is applied.
+const AppliedEvent = "migrate.applied"
+
+var ErrNoDown = errors.New("no down migration")
+
+// migratingKey is the context key of the migrations.
+type migratingKey struct{}
+
+// IsMigrating reports whether the ctx is of the Migrator, the statements of
+// the migrations should not be bounded by the statement timeouts.
+func IsMigrating(ctx context.Context) bool {
+	v, _ := ctx.Value(migratingKey{}).(bool)
+	return v
+}
+
+// Direction of a Step.
+type Direction string
+
+const (
+	Up   Direction = "up"
+	Down Direction = "down"
+)
+
+// Step is an applied or planned migration.
+type Step struct {
+	Migration
+	Direction Direction
+	SQL       string        // SQL executed by the step.
+	Elapsed   time.Duration // Elapsed time of the step, zero in dry run.
+	DryRun    bool          // DryRun step is planned but not executed.
+}
+
+// Status of a migration.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	Missing   bool // Missing is the applied version without file.
+}
+
+// String returns the status line like "applied 0001_create_users".
+func (s Status) String() string {
+	switch {
+	case s.Missing:
+		return fmt.Sprintf("missing %04d", s.Version)
+	case s.Applied:
+		return fmt.Sprintf("applied %s (%s)", s.Migration, s.AppliedAt.Format(time.RFC3339))
+	default:
+		return fmt.Sprintf("pending %s", s.Migration)
+	}
+}
+
+// Migrator applies the migrations of the file system to the database.
+type Migrator struct {
+	db      *gorm.DB
+	fs      FileSystem
+	options *Options
+}
+
+// New Migrator of the database and the file system with options.
+func New(db *gorm.DB, fs FileSystem, opts ...Option) *Migrator {
+	options := GetDefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return &Migrator{db: db, fs: fs, options: options}
+}
+
+// Up applies the pending migrations in version order, it returns the applied
+// steps, or the planned steps in dry run which neither takes the lock nor
+// writes the database.
+func (m *Migrator) Up(ctx context.Context) (steps []Step, err error) {
+	ctx = context.WithValue(ctx, migratingKey{}, true)
+	migrations, err := Load(m.fs)
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.options.DryRun {
+		var unlock func() error
+		if unlock, err = m.lock(ctx); err != nil {
+			return nil, err
+		}
+		defer func() {
+			err = multierr.Append(err, unlock())
+		}()
+	}
+
+	applied, err := m.applied(ctx, !m.options.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		step, err := m.apply(ctx, Step{Migration: migration, Direction: Up, SQL: migration.Up})
+		if err != nil {
+			return steps, err
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// Down reverts the last n applied migrations in reverse version order, it
+// returns the reverted steps, or the planned steps in dry run.
+func (m *Migrator) Down(ctx context.Context, n int) (steps []Step, err error) {
+	ctx = context.WithValue(ctx, migratingKey{}, true)
+	migrations, err := Load(m.fs)
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.options.DryRun {
+		var unlock func() error
+		if unlock, err = m.lock(ctx); err != nil {
+			return nil, err
+		}
+		defer func() {
+			err = multierr.Append(err, unlock())
+		}()
+	}
+
+	applied, err := m.applied(ctx, !m.options.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(migrations) - 1; i >= 0 && len(steps) < n; i-- {
+		var migration = migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return steps, fmt.Errorf("%w: %s", ErrNoDown, migration)
+		}
+		step, err := m.apply(ctx, Step{Migration: migration, Direction: Down, SQL: migration.Down})
+		if err != nil {
+			return steps, err
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// Status returns the status of the migrations in version order, the applied
+// versions without file are missing.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	ctx = context.WithValue(ctx, migratingKey{}, true)
+	migrations, err := Load(m.fs)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Status
+	for _, migration := range migrations {
+		appliedAt, ok := applied[migration.Version]
+		list = append(list, Status{Migration: migration, Applied: ok, AppliedAt: appliedAt})
+		delete(applied, migration.Version)
+	}
+	for version, appliedAt := range applied {
+		list = append(list, Status{Migration: Migration{Version: version}, Applied: true, AppliedAt: appliedAt, Missing: true})
+	}
+	sortStatus(list)
+
+	return list, nil
+}
+
+// applied returns the applied time of the applied versions, the table is
+// created if not exists and create is true.
+func (m *Migrator) applied(ctx context.Context, create bool) (map[int64]time.Time, error) {
+	var db = m.db.WithContext(ctx)
+	if !db.Migrator().HasTable(m.options.Table) {
+		if !create {
+			return map[int64]time.Time{}, nil
+		}
+		err := db.Exec(fmt.Sprintf("CREATE TABLE %s (version BIGINT NOT NULL PRIMARY KEY, "+
+			"name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL)", m.options.Table)).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var rows []struct {
+		Version   int64
+		AppliedAt time.Time
+	}
+	if err := db.Table(m.options.Table).Select("version, applied_at").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var applied = make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+// apply executes the step and records its version in a transaction, the
+// AppliedEvent is published after.
+func (m *Migrator) apply(ctx context.Context, step Step) (Step, error) {
+	if m.options.DryRun {
+		step.DryRun = true
+		return step, nil
+	}
+
+	var start = time.Now()
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(step.SQL).Error; err != nil {
+			return err
+		}
+		if step.Direction == Down {
+			return tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE version = ?", m.options.Table), step.Version).Error
+		}
+		return tx.Exec(fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (?, ?, ?)", m.options.Table),
+			step.Version, step.Name, time.Now().UTC()).Error
+	})
+	if err != nil {
+		return step, fmt.Errorf("%s %s: %w", step.Direction, step.Migration, err)
+	}
+	step.Elapsed = time.Since(start)
+
+	if m.options.Event != nil {
+		if err := m.options.Event.Publish(ctx, AppliedEvent, step); err != nil && !errors.Is(err, inapp.ErrNotExistEvent) {
+			return step, err
+		}
+	}
+
+	return step, nil
+}
+
+// sortStatus sorts the status list by version.
+func sortStatus(list []Status) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+}
@@ -0,0 +1,188 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/go-framework/event/inapp"
+)
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(MapFS{
+		"0002_add_email.up.sql":      "ALTER TABLE users ADD COLUMN email TEXT;",
+		"0001_create_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY);",
+		"0001_create_users.down.sql": "DROP TABLE users;",
+		"README.md":                  "migrations",
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	var names []string
+	for _, m := range migrations {
+		names = append(names, m.String())
+	}
+	if want := []string{"0001_create_users", "0002_add_email"}; !reflect.DeepEqual(names, want) || migrations[0].Down != "DROP TABLE users;" {
+		t.Errorf("Load() = %v, want %v", migrations, want)
+	}
+
+	if _, err := Load(MapFS{"0001_a.up.sql": "", "0001_b.up.sql": ""}); !errors.Is(err, ErrDuplicateVersion) {
+		t.Errorf("Load() error = %v, want %v", err, ErrDuplicateVersion)
+	}
+	if _, err := Load(MapFS{"0001_a.down.sql": ""}); !errors.Is(err, ErrMissingUp) {
+		t.Errorf("Load() error = %v, want %v", err, ErrMissingUp)
+	}
+}
+
+func newTestDB(t *testing.T, dir string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "app.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func hasColumn(db *gorm.DB, table, column string) bool {
+	return db.Exec("SELECT "+column+" FROM "+table).Error == nil
+}
+
+func TestMigrator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var files = filepath.Join(dir, "migrations")
+	os.Mkdir(files, 0755)
+	for name, content := range map[string]string{
+		"0001_create_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY);",
+		"0001_create_users.down.sql": "DROP TABLE users;",
+		"0002_add_email.up.sql":      "ALTER TABLE users ADD COLUMN email TEXT; CREATE INDEX users_email ON users (email);",
+		// the bundled sqlite can not drop columns.
+		"0002_add_email.down.sql": "DROP INDEX users_email; ALTER TABLE users RENAME COLUMN email TO removed_email;",
+	} {
+		ioutil.WriteFile(filepath.Join(files, name), []byte(content), 0644)
+	}
+
+	var db = newTestDB(t, dir)
+	var event = inapp.NewEvent()
+	var applied = make(chan Step, 10)
+	event.Subscribe(context.TODO(), AppliedEvent, func(ctx context.Context, args ...interface{}) error {
+		applied <- args[0].(Step)
+		return nil
+	})
+
+	// dry run neither executes nor records.
+	steps, err := New(db, Dir(files), WithDryRunOption(true)).Up(context.TODO())
+	if err != nil || len(steps) != 2 || !steps[0].DryRun || db.Migrator().HasTable("users") || db.Migrator().HasTable("schema_migrations") {
+		t.Fatalf("Up() dry run = %v, error = %v", steps, err)
+	}
+
+	var m = New(db, Dir(files), WithEventOption(event))
+	steps, err = m.Up(context.TODO())
+	if err != nil || len(steps) != 2 || !hasColumn(db, "users", "email") {
+		t.Fatalf("Up() = %v, error = %v", steps, err)
+	}
+	for _, want := range []string{"0001_create_users", "0002_add_email"} {
+		select {
+		case step := <-applied:
+			if step.String() != want || step.Direction != Up {
+				t.Errorf("AppliedEvent = %v, want %s", step, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("AppliedEvent timeout")
+		}
+	}
+
+	ioutil.WriteFile(filepath.Join(files, "0003_broken.up.sql"), []byte("CREATE TABLE orders (id INTEGER PRIMARY KEY); INSERT INTO missing VALUES (1);"), 0644)
+	if steps, err := m.Up(context.TODO()); err == nil || len(steps) != 0 || db.Migrator().HasTable("orders") {
+		t.Errorf("Up() = %v, error = %v, want rolled back", steps, err)
+	}
+	os.Remove(filepath.Join(files, "0003_broken.up.sql"))
+
+	status, err := m.Status(context.TODO())
+	if err != nil || len(status) != 2 || !status[0].Applied || !status[1].Applied {
+		t.Fatalf("Status() = %v, error = %v", status, err)
+	}
+
+	steps, err = m.Down(context.TODO(), 1)
+	if err != nil || len(steps) != 1 || steps[0].Version != 2 || hasColumn(db, "users", "email") {
+		t.Fatalf("Down() = %v, error = %v", steps, err)
+	}
+	if status, _ := m.Status(context.TODO()); status[1].Applied || status[1].String() != "pending 0002_add_email" {
+		t.Errorf("Status() = %v", status)
+	}
+}
+
+func TestMigrator_Lock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var fs = MapFS{"0001_create_users.up.sql": "CREATE TABLE users (id INTEGER PRIMARY KEY);"}
+	var m = New(newTestDB(t, dir), fs)
+	unlock, err := m.lock(context.TODO())
+	if err != nil {
+		t.Fatalf("lock() error = %v", err)
+	}
+
+	var other = New(newTestDB(t, dir), fs, WithLockTimeoutOption(200*time.Millisecond))
+	if _, err := other.Up(context.TODO()); !errors.Is(err, ErrLocked) || !strings.Contains(err.Error(), "schema_migrations_lock") {
+		t.Errorf("Up() error = %v, want %v", err, ErrLocked)
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock() error = %v", err)
+	}
+	if steps, err := other.Up(context.TODO()); err != nil || len(steps) != 1 {
+		t.Errorf("Up() = %v, error = %v", steps, err)
+	}
+
+	// the errors of the lock table are not taken as a held lock.
+	var db = newTestDB(t, dir)
+	db.Exec("DROP TABLE schema_migrations_lock")
+	db.Exec("CREATE TABLE schema_migrations_lock (id INTEGER NOT NULL PRIMARY KEY, locked_at TIMESTAMP NOT NULL, holder TEXT NOT NULL)")
+	if _, err := other.Up(context.TODO()); err == nil || errors.Is(err, ErrLocked) {
+		t.Errorf("Up() error = %v, want the insert error", err)
+	}
+
+	db.Exec("DROP TABLE schema_migrations_lock")
+	if unlock, err = m.lock(context.TODO()); err != nil {
+		t.Fatalf("lock() error = %v", err)
+	}
+	db.Exec("DROP TABLE schema_migrations_lock")
+	if err := unlock(); err == nil {
+		t.Errorf("unlock() want the delete error")
+	}
+}
+
+func TestMigrator_SessionLockTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var m = New(newTestDB(t, dir), MapFS{})
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+
+	// the lock query is still running when the lock timeout expires.
+	var slow = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < ?) SELECT count(*) = 0 FROM c"
+	if _, err := m.sessionLock(ctx, slow, "SELECT ?", int64(1e10)); !errors.Is(err, ErrLocked) {
+		t.Errorf("sessionLock() error = %v, want %v", err, ErrLocked)
+	}
+}
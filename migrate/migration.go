@@ -0,0 +1,86 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var (
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+	ErrMissingUp        = errors.New("missing up migration")
+)
+
+// fileName matches the migration file names like 0001_create_users.up.sql.
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a version of the schema, it is read from the NNNN_name.up.sql
+// and the optional NNNN_name.down.sql files.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string // Up is the SQL which applies the version.
+	Down    string // Down is the SQL which reverts the version, it may be empty.
+}
+
+// String returns the file name prefix of the migration, like 0001_create_users.
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Load the migrations of the file system in version order, the files which
+// do not match the NNNN_name.up.sql or NNNN_name.down.sql names are ignored.
+func Load(fs FileSystem) ([]Migration, error) {
+	names, err := fs.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		migrations = make(map[int64]*Migration)
+		ups        = make(map[int64]bool)
+	)
+	for _, name := range names {
+		var match = fileName.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		data, err := fs.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		var m, ok = migrations[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			migrations[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("%w: %d of %s and %s", ErrDuplicateVersion, version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up, ups[version] = string(data), true
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	var list = make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		if !ups[m.Version] {
+			return nil, fmt.Errorf("%w: %s", ErrMissingUp, m)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+
+	return list, nil
+}
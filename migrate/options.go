@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"time"
+
+	"github.com/go-framework/event/inapp"
+)
+
+// Migrator option func.
+type Option func(options *Options)
+
+// Migrator options.
+type Options struct {
+	Table       string        // Table of the applied versions, default is "schema_migrations".
+	LockTimeout time.Duration // Timeout of taking the lock, default is 1 minute.
+	DryRun      bool          // DryRun plans the steps without executing them.
+	Event       *inapp.Event  // Event bus of the AppliedEvent, nil disables the events.
+}
+
+// Get default Options value.
+func GetDefaultOptions() *Options {
+	return &Options{
+		Table:       "schema_migrations",
+		LockTimeout: time.Minute,
+	}
+}
+
+// WithTableOption set the table of the applied versions.
+func WithTableOption(table string) Option {
+	return func(options *Options) {
+		options.Table = table
+	}
+}
+
+// WithLockTimeoutOption set the timeout of taking the lock.
+func WithLockTimeoutOption(timeout time.Duration) Option {
+	return func(options *Options) {
+		options.LockTimeout = timeout
+	}
+}
+
+// WithDryRunOption set the dry run mode.
+func WithDryRunOption(dryRun bool) Option {
+	return func(options *Options) {
+		options.DryRun = dryRun
+	}
+}
+
+// WithEventOption set the event bus of the AppliedEvent.
+func WithEventOption(event *inapp.Event) Option {
+	return func(options *Options) {
+		options.Event = event
+	}
+}
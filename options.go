@@ -0,0 +1,140 @@
+package app
+
+import (
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/go-framework/configurer"
+	"github.com/go-framework/configurer/schema"
+	"github.com/go-framework/configurer/templates/environment"
+	"github.com/go-framework/event/inapp"
+	"github.com/go-framework/logger"
+)
+
+// App option func.
+type Option func(options *Options)
+
+// App options.
+type Options struct {
+	Name        string                  // Application name.
+	Environment environment.Environment // Application run environment, default is environment.Current.
+	Logger      *logger.Logger          // Application logger, default is logger.DefaultLogger.
+	Event       *inapp.Event            // Application event bus, default is a new inapp.Event.
+	Config      configurer.Configurer   // Application configuration, it is watched while running.
+	Loader      ConfigLoader            // Application configuration loader of the environments, used by the config commands.
+	Level       zap.AtomicLevel         // Application logger level, it follows the configuration "logger.level" when set.
+	Schema      *schema.Schema          // Application configuration schema, default is NewConfigSchema.
+
+	Signals         []os.Signal              // Signals trapped by Run to shutdown, default is SIGINT, SIGTERM and SIGQUIT.
+	ShutdownTimeout time.Duration            // Overall shutdown budget, default is 30 seconds.
+	StopTimeout     time.Duration            // Default stop deadline of each component, default is 10 seconds.
+	StopTimeouts    map[string]time.Duration // Stop deadline by component name, it overrides the StopTimeout.
+}
+
+// Get default Options value.
+func GetDefaultOptions() *Options {
+	opts := &Options{
+		Name:        "app",
+		Environment: environment.Current(),
+		Logger:      logger.DefaultLogger,
+		Event:       inapp.NewEvent(),
+
+		Signals:         DefaultSignals,
+		ShutdownTimeout: 30 * time.Second,
+		StopTimeout:     10 * time.Second,
+		StopTimeouts:    make(map[string]time.Duration),
+	}
+	return opts
+}
+
+// WithNameOption set the application name.
+func WithNameOption(name string) Option {
+	return func(options *Options) {
+		options.Name = name
+	}
+}
+
+// WithEnvironmentOption set the application run environment, it is set as
+// the environment.Current like the --env flag.
+func WithEnvironmentOption(env environment.Environment) Option {
+	return func(options *Options) {
+		options.Environment = env
+		environment.SetCurrent(env)
+	}
+}
+
+// WithLoggerOption set the application logger.
+func WithLoggerOption(log *logger.Logger) Option {
+	return func(options *Options) {
+		options.Logger = log
+	}
+}
+
+// WithEventOption set the application event bus.
+func WithEventOption(event *inapp.Event) Option {
+	return func(options *Options) {
+		options.Event = event
+	}
+}
+
+// WithLevelOption set the application logger level which follows the
+// configuration "logger.level", it should be the level of the logger config.
+func WithLevelOption(level zap.AtomicLevel) Option {
+	return func(options *Options) {
+		options.Level = level
+	}
+}
+
+// WithConfigOption set the application configuration.
+func WithConfigOption(config configurer.Configurer) Option {
+	return func(options *Options) {
+		options.Config = config
+	}
+}
+
+// WithConfigLoaderOption set the application configuration loader which
+// loads the configuration of any environment, like the production
+// configuration for the config print and diff commands.
+func WithConfigLoaderOption(loader ConfigLoader) Option {
+	return func(options *Options) {
+		options.Loader = loader
+	}
+}
+
+// WithSchemaOption set the application configuration schema used by the
+// config schema and validate commands.
+func WithSchemaOption(s *schema.Schema) Option {
+	return func(options *Options) {
+		options.Schema = s
+	}
+}
+
+// WithSignalsOption set the signals trapped by Run, no signal is trapped when empty.
+func WithSignalsOption(signals ...os.Signal) Option {
+	return func(options *Options) {
+		options.Signals = signals
+	}
+}
+
+// WithShutdownTimeoutOption set the overall shutdown budget.
+func WithShutdownTimeoutOption(timeout time.Duration) Option {
+	return func(options *Options) {
+		options.ShutdownTimeout = timeout
+	}
+}
+
+// WithStopTimeoutOption set the default stop deadline of each component.
+func WithStopTimeoutOption(timeout time.Duration) Option {
+	return func(options *Options) {
+		options.StopTimeout = timeout
+	}
+}
+
+// WithComponentStopTimeoutOption set the stop deadline of the named component.
+func WithComponentStopTimeoutOption(name string, timeout time.Duration) Option {
+	return func(options *Options) {
+		options.StopTimeouts[name] = timeout
+	}
+}
@@ -0,0 +1,22 @@
+package app
+
+import (
+	"github.com/go-framework/configurer/templates/environment"
+)
+
+func init() {
+	environment.RegisterPolicy("logger-development", environment.Forbid("logger.development", true,
+		environment.Environment.IsProductionLike, "development logger is forbidden in production-like environments"))
+	environment.RegisterPolicy("sql-sqlite", environment.Forbid("sql.driverName", "sqlite",
+		environment.Environment.IsProductionLike, "sqlite driver is forbidden in production-like environments"))
+}
+
+// checkPolicies checks the application configuration by the registered
+// environment policies, it returns the *environment.PolicyError which lists
+// all violations.
+func (a *App) checkPolicies() error {
+	if a.Config() == nil {
+		return nil
+	}
+	return environment.CheckPolicies(a.Environment(), a.Config())
+}
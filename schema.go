@@ -0,0 +1,89 @@
+package app
+
+import (
+	"go/build"
+	"reflect"
+
+	"github.com/go-framework/configurer/schema"
+	"github.com/go-framework/configurer/templates/environment"
+	"github.com/go-framework/configurer/templates/sql"
+	"github.com/go-framework/logger"
+)
+
+//go:generate go run ./cmd/config-schema -o config.schema.json
+
+// Config is the configuration of the framework components, the application
+// configuration files have these keys at top level.
+type Config struct {
+	// Application run environment.
+	Environment environment.Environment `json:"environment" yaml:"environment" default:"development"`
+	// Application logger.
+	Logger logger.Config `json:"logger" yaml:"logger"`
+	// Application database.
+	SQL sql.Config `json:"sql" yaml:"sql"`
+}
+
+// configSchemaPackages are the packages which describe the Config schema by
+// their doc comments.
+var configSchemaPackages = []string{
+	"github.com/go-framework/app",
+	"github.com/go-framework/configurer/templates/environment",
+	"github.com/go-framework/configurer/templates/sql",
+	"github.com/go-framework/logger",
+	"github.com/go-framework/logger/writers/file-rotatelogs",
+	"github.com/natefinch/lumberjack",
+	"go.uber.org/zap",
+	"go.uber.org/zap/zapcore",
+}
+
+// NewConfigSchema returns the JSON Schema of the Config, the logger writes
+// match the writer configurations of the registered logger writers. Other
+// top level keys are allowed for the application configuration.
+func NewConfigSchema(opts ...schema.Option) *schema.Schema {
+	var write = definition(logger.Write{}, opts...)
+	for _, name := range logger.WriterNames() {
+		var branch = &schema.Schema{
+			Properties: map[string]*schema.Schema{"name": {Const: name}},
+			Required:   []string{"name"},
+		}
+
+		writer, _ := logger.GetWriter(name)
+		if typ := reflect.TypeOf(writer); typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Struct {
+			// writers without configurable fields like os.Stdout accept any config.
+			if config := definition(reflect.New(typ.Elem()).Elem().Interface(), opts...); len(config.Properties) > 0 {
+				branch.Properties["writer"] = config
+			}
+		}
+
+		write.OneOf = append(write.OneOf, branch)
+	}
+
+	opts = append(opts, schema.WithTypeOption(reflect.TypeOf(logger.Write{}), write))
+	var s = schema.Generate(Config{}, opts...)
+	s.AdditionalProperties = nil
+
+	return s
+}
+
+// definition generates the schema of v which is a part of a document.
+func definition(v interface{}, opts ...schema.Option) *schema.Schema {
+	var s = schema.Generate(v, opts...)
+	s.Version, s.ID, s.Title = "", "", ""
+	return s
+}
+
+// LoadConfigSchemaComments parses the doc comments of the Config packages
+// from their source directories, it is used to generate the committed schema.
+func LoadConfigSchemaComments() (schema.Comments, error) {
+	var comments = make(schema.Comments)
+	for _, path := range configSchemaPackages {
+		pkg, err := build.Import(path, ".", build.FindOnly)
+		if err != nil {
+			return nil, err
+		}
+		if err := comments.Parse(path, pkg.Dir); err != nil {
+			return nil, err
+		}
+	}
+	return comments, nil
+}
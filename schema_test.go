@@ -0,0 +1,104 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-framework/configurer/providers/file"
+	"github.com/go-framework/configurer/schema"
+)
+
+func TestConfigSchema_Committed(t *testing.T) {
+	comments, err := LoadConfigSchemaComments()
+	if err != nil {
+		t.Skipf("LoadConfigSchemaComments() error = %v", err)
+	}
+	want, err := json.MarshalIndent(NewConfigSchema(
+		schema.WithTitleOption("go-framework application configuration"),
+		schema.WithCommentsOption(comments),
+	), "", "  ")
+	if err != nil {
+		t.Fatalf("MarshalIndent() error = %v", err)
+	}
+
+	got, err := ioutil.ReadFile("config.schema.json")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(got) != string(want)+"\n" {
+		t.Errorf("config.schema.json is out of date, run go generate")
+	}
+}
+
+func TestConfigSchema_LoggerTemplates(t *testing.T) {
+	var s = NewConfigSchema().Properties["logger"]
+
+	paths, _ := filepath.Glob("vendor/github.com/go-framework/logger/templates/*")
+	for _, path := range paths {
+		m, err := file.NewProvider(path).Read()
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if err := s.Validate(m); err != nil {
+			t.Errorf("Validate(%s) error = %v", path, err)
+		}
+	}
+}
+
+func TestConfigCommand_Validate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var good = filepath.Join(dir, "good.yaml")
+	var bad = filepath.Join(dir, "bad.yaml")
+	ioutil.WriteFile(good, []byte(`
+environment: prod
+name: ${APP_TEST_UNSET_NAME}
+sql:
+  driverName: mysql
+  dsn: ENC[AES256_GCM,bm90IGVuY3J5cHRlZA==]
+logger:
+  level: info
+  writes:
+    - name: lumberjack
+      writer:
+        filename: app.log
+`), 0644)
+	ioutil.WriteFile(bad, []byte(`
+environment: space
+sql:
+  driverName: mysql
+logger:
+  writes:
+    - name: lumberjack
+      writer:
+        maxsize: big
+`), 0644)
+
+	var root = NewRootCommand(New(WithNameOption("demo")))
+	var out = new(bytes.Buffer)
+	root.SetOutput(out)
+
+	err = root.Execute(context.TODO(), []string{"config", "validate", good, bad})
+	if out.String() != good+": ok\n" {
+		t.Errorf("validate = %q", out.String())
+	}
+	for _, want := range []string{
+		bad + ": environment: space must be one of",
+		bad + ": logger.writes[0]: must match exactly one schema, matched 0",
+		bad + ": sql.dsn: is required",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Execute() error = %v, want contains %q", err, want)
+		}
+	}
+}
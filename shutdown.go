@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"github.com/go-framework/logger"
+)
+
+// shutdownContext returns the context passed by Shutdown, or background when
+// the application is stopped by its parent context or a signal, limited by
+// the overall shutdown budget.
+func (a *App) shutdownContext() (context.Context, context.CancelFunc) {
+	a.mu.Lock()
+	var ctx = a.stopCtx
+	a.mu.Unlock()
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if a.options.ShutdownTimeout > 0 {
+		return context.WithTimeout(ctx, a.options.ShutdownTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// stopTimeout returns the stop deadline of the component.
+func (a *App) stopTimeout(component Component) time.Duration {
+	if timeout, ok := a.options.StopTimeouts[component.Name()]; ok {
+		return timeout
+	}
+	if v, ok := component.(StopTimeouter); ok {
+		return v.StopTimeout()
+	}
+	return a.options.StopTimeout
+}
+
+// stop the started components in reverse order and collect their errors.
+func (a *App) stop(ctx context.Context, started []Component) (errs error) {
+	for i := len(started) - 1; i >= 0; i-- {
+		if err := a.stopComponent(ctx, started[i]); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("stop %s: %w", started[i].Name(), err))
+			continue
+		}
+		a.Logger().Debug("component stopped", zap.String("component", started[i].Name()))
+	}
+	return
+}
+
+// stopComponent stops the component within its deadline, a component which
+// does not return in time is abandoned with the context error.
+func (a *App) stopComponent(ctx context.Context, component Component) error {
+	if timeout := a.stopTimeout(component); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var errCh = make(chan error, 1)
+	go func() {
+		errCh <- component.Stop(ctx)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		select {
+		case err := <-errCh:
+			return err
+		default:
+			return ctx.Err()
+		}
+	}
+}
+
+// drain waits for the in-flight event publishes, then flushes the logger. The
+// registered log writers are shared by the process and stay open, the logs
+// after Run returns are still written.
+func (a *App) drain(ctx context.Context) (errs error) {
+	if a.Event() != nil {
+		if err := a.Event().Wait(ctx); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("drain event: %w", err))
+		}
+	}
+
+	if a.Logger() != nil {
+		errs = multierr.Append(errs, syncLogger(a.Logger()))
+	}
+
+	return
+}
+
+// syncLogger flushes the logger, the errors of standard streams which can't
+// be synced are ignored.
+func syncLogger(log *logger.Logger) (errs error) {
+	for _, err := range multierr.Errors(log.Sync()) {
+		if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY) {
+			continue
+		}
+		errs = multierr.Append(errs, fmt.Errorf("sync logger: %w", err))
+	}
+	return
+}
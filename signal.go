@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// DefaultSignals are the signals trapped by Run to shutdown.
+var DefaultSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT}
+
+// notify cancels the run context when one of the options signals received,
+// the returned func stops the trapping.
+func (a *App) notify(cancel context.CancelFunc) (stop func()) {
+	if len(a.options.Signals) == 0 {
+		return func() {}
+	}
+
+	var ch = make(chan os.Signal, 1)
+	var done = make(chan struct{})
+
+	signal.Notify(ch, a.options.Signals...)
+
+	go func() {
+		select {
+		case sig := <-ch:
+			a.Logger().Info("received signal, shutting down", zap.Stringer("signal", sig))
+			cancel()
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
@@ -0,0 +1,42 @@
+//go:build !windows
+// +build !windows
+
+package app
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestApp_Signal(t *testing.T) {
+	var r = &recorder{}
+	var a = New(WithSignalsOption(syscall.SIGUSR1))
+
+	a.Register(&testComponent{name: "a", recorder: r})
+
+	var errCh = make(chan error, 1)
+	go func() {
+		errCh <- a.Run(context.Background())
+	}()
+
+	// wait for started.
+	for i := 0; i < 100 && len(r.list()) < 2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("Kill() error = %v", err)
+	}
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run() is not stopped by signal")
+	}
+}
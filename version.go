@@ -0,0 +1,27 @@
+package app
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Build information, set by the linker flags, like
+//
+//	-ldflags "-X github.com/go-framework/app.Version=v1.0.0"
+var (
+	Version   = "dev"
+	GitCommit = ""
+	BuildTime = ""
+)
+
+// VersionString returns the build information of the application.
+func VersionString(name string) string {
+	var s = fmt.Sprintf("%s %s", name, Version)
+	if GitCommit != "" {
+		s += " (" + GitCommit + ")"
+	}
+	if BuildTime != "" {
+		s += " built at " + BuildTime
+	}
+	return fmt.Sprintf("%s %s %s/%s", s, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}